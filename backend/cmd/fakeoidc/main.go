@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"strings"
+
+	"anyrouter-checkin/internal/oidc/fake"
+	"anyrouter-checkin/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// 本地模拟 OIDC 身份提供方，配合 oidc.issuer 联调单点登录、组映射与自动开通。
+func main() {
+	addr := flag.String("addr", ":3002", "监听地址")
+	issuer := flag.String("issuer", "http://localhost:3002", "签发方地址，需与 oidc.issuer 一致")
+	clientID := flag.String("client-id", "anyrouter-checkin", "客户端 ID")
+	clientSecret := flag.String("client-secret", "secret", "客户端密钥，为空时不校验")
+	users := flag.String("users", "alice:anyrouter-admins,bob:anyrouter-operators,carol:anyrouter-viewers", "演示用户，格式 用户名:组1|组2，多个用户以逗号分隔")
+	flag.Parse()
+
+	zapLogger, err := logger.Init("debug")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = zapLogger.Sync()
+	}()
+
+	server := fake.New(*clientID, *clientSecret)
+	server.SetIssuer(*issuer)
+	for _, item := range strings.Split(*users, ",") {
+		username, groups, _ := strings.Cut(strings.TrimSpace(item), ":")
+		if username == "" {
+			continue
+		}
+		var groupList []string
+		if groups != "" {
+			groupList = strings.Split(groups, "|")
+		}
+		server.AddUser(username, groupList...)
+		zap.L().Info("演示用户", zap.String("username", username), zap.Strings("groups", groupList))
+	}
+
+	zap.L().Info("模拟 OIDC 身份提供方已启动", zap.String("addr", *addr), zap.String("issuer", *issuer), zap.String("client_id", *clientID))
+	zap.L().Info("授权时可通过 login_hint 参数选择用户，未指定时使用第一个用户")
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		zap.L().Fatal("启动失败", zap.Error(err))
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"anyrouter-checkin/internal/upstream/fake"
+	"anyrouter-checkin/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// 本地模拟 AnyRouter 上游，配合 upstream.base_url 联调签到、余额与 WAF 流程。
+func main() {
+	addr := flag.String("addr", ":3001", "监听地址")
+	username := flag.String("user", "demo", "演示账号用户名")
+	quota := flag.Int64("quota", 5000000, "演示账号初始 quota")
+	challenge := flag.Bool("challenge", false, "启用 acw_sc__v2 WAF 挑战页")
+	rotate := flag.Bool("rotate", false, "每次认证请求轮换 session")
+	flag.Parse()
+
+	zapLogger, err := logger.Init("debug")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = zapLogger.Sync()
+	}()
+
+	server := fake.New()
+	server.SetChallenge(*challenge)
+	server.SetSessionRotation(*rotate)
+	id, session := server.AddUser(*username, *quota)
+
+	zap.L().Info("模拟上游已启动", zap.String("addr", *addr), zap.Bool("challenge", *challenge), zap.Bool("rotate", *rotate))
+	zap.L().Info("演示账号", zap.Int("user_id", id), zap.String("username", *username), zap.String("session", session))
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		zap.L().Fatal("启动失败", zap.Error(err))
+	}
+}
@@ -14,6 +14,7 @@ import (
 	_ "anyrouter-checkin/docs"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -29,10 +30,14 @@ import (
 func main() {
 	if err := config.Load(); err != nil {
 		fallback, _ := zap.NewDevelopment()
+		if viper.ConfigFileUsed() == "" {
+			fallback.Fatal("加载配置失败", zap.Error(err),
+				zap.String("hint", "未找到 config.yaml，请挂载配置文件或设置 ANYROUTER_JWT_SECRET、ANYROUTER_AES_KEY 与 ANYROUTER_ADMIN_PASSWORD 等环境变量"))
+		}
 		fallback.Fatal("加载配置失败", zap.Error(err))
 	}
 
-	zapLogger, err := logger.Init(config.C.Server.Mode)
+	zapLogger, err := logger.Init(config.Get().Server.Mode)
 	if err != nil {
 		fallback, _ := zap.NewDevelopment()
 		fallback.Fatal("初始化日志失败", zap.Error(err))
@@ -40,33 +45,60 @@ func main() {
 	defer func() {
 		_ = zapLogger.Sync()
 	}()
+	if file := viper.ConfigFileUsed(); file != "" {
+		zap.L().Info("已加载配置文件", zap.String("file", file))
+	} else {
+		zap.L().Info("未找到配置文件，使用默认值与环境变量", zap.String("prefix", config.EnvPrefix+"_"))
+	}
+	if err := logger.SetLevel(config.Get().Server.Mode, config.Get().Log.Level); err != nil {
+		zap.L().Fatal("设置日志级别失败", zap.Error(err))
+	}
+	for _, issue := range config.Get().InsecureSettings() {
+		zap.L().Warn("配置不安全，release 模式下将拒绝启动", zap.String("issue", issue))
+	}
 
-	dbDir := filepath.Dir(config.C.Database.Path)
-	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		zap.L().Fatal("创建数据目录失败", zap.Error(err))
+	dbConfig := config.Get().Database
+	if dbConfig.Driver == "sqlite" {
+		if err := os.MkdirAll(filepath.Dir(dbConfig.Path), 0755); err != nil {
+			zap.L().Fatal("创建数据目录失败", zap.Error(err))
+		}
 	}
 
-	if err := repository.Init(config.C.Database.Path); err != nil {
+	if err := repository.Init(dbConfig); err != nil {
 		zap.L().Fatal("初始化数据库失败", zap.Error(err))
 	}
 
-	repository.InitDefaultConfigs()
+	if err := service.InitDefaultConfigs(); err != nil {
+		zap.L().Fatal("初始化系统配置失败", zap.Error(err))
+	}
 	if err := service.InitAdminUser(); err != nil {
 		zap.L().Fatal("初始化管理员失败", zap.Error(err))
 	}
 
+	service.InitUpstream(config.Get().Upstream.BaseURL)
 	service.InitCron()
 
-	gin.SetMode(config.C.Server.Mode)
+	gin.SetMode(config.Get().Server.Mode)
 	gin.DefaultWriter = logger.Writer(zapLogger, zapcore.InfoLevel)
 	gin.DefaultErrorWriter = logger.Writer(zapLogger, zapcore.ErrorLevel)
 	r := gin.Default()
-	if err := r.SetTrustedProxies(nil); err != nil {
+	if err := r.SetTrustedProxies(config.Get().Server.TrustedProxies); err != nil {
 		zap.L().Fatal("设置 TrustedProxies 失败", zap.Error(err))
 	}
 	router.Setup(r)
 
-	addr := fmt.Sprintf(":%d", config.C.Server.Port)
+	config.Subscribe(func(old, new *config.Config) {
+		if err := logger.SetLevel(new.Server.Mode, new.Log.Level); err != nil {
+			zap.L().Error("设置日志级别失败", zap.Error(err))
+		}
+		if new.Server.Mode != old.Server.Mode {
+			gin.SetMode(new.Server.Mode)
+			zap.L().Info("运行模式已更新，日志格式需重启后生效", zap.String("mode", new.Server.Mode))
+		}
+	})
+	config.Watch()
+
+	addr := fmt.Sprintf(":%d", config.Get().Server.Port)
 	zap.L().Info("服务启动", zap.String("addr", "http://localhost"+addr))
 	zap.L().Info("Swagger", zap.String("url", "http://localhost"+addr+"/swagger/index.html"))
 	if err := r.Run(addr); err != nil {
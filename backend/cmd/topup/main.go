@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// 批量使用兑换码为账号充值，兑换码来自命令行参数或文件（每行一个，"-" 表示标准输入）。
+//
+//	go run ./cmd/topup -accounts 1,2 -file codes.txt
+//	go run ./cmd/topup -accounts 1 CODE-1 CODE-2
+func main() {
+	accountsFlag := flag.String("accounts", "", "目标账号ID，逗号分隔")
+	file := flag.String("file", "", "兑换码文件，每行一个，- 表示标准输入")
+	flag.Parse()
+
+	accountIDs, err := parseAccountIDs(*accountsFlag)
+	if err != nil || len(accountIDs) == 0 {
+		fmt.Fprintln(os.Stderr, "请通过 -accounts 指定目标账号ID，例如 -accounts 1,2")
+		os.Exit(2)
+	}
+	codes := flag.Args()
+	if *file != "" {
+		fileCodes, err := readCodes(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "读取兑换码失败:", err)
+			os.Exit(1)
+		}
+		codes = append(codes, fileCodes...)
+	}
+
+	if err := config.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, "加载配置失败:", err)
+		os.Exit(1)
+	}
+	zapLogger, err := logger.Init(config.Get().Server.Mode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "初始化日志失败:", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = zapLogger.Sync()
+	}()
+	if err := repository.Init(config.Get().Database); err != nil {
+		zap.L().Fatal("初始化数据库失败", zap.Error(err))
+	}
+	service.InitUpstream(config.Get().Upstream.BaseURL)
+
+	result, err := service.RedeemCodes(codes, accountIDs)
+	if err != nil {
+		zap.L().Fatal("充值失败", zap.Error(err))
+	}
+
+	// 充值结果是命令的输出而非日志，直接写到标准输出，便于重定向或交给其他工具处理。
+	failed := 0
+	for _, item := range result.Codes {
+		status := "成功"
+		if !item.Success {
+			status = "失败"
+			failed++
+		}
+		fmt.Fprintf(os.Stdout, "账号 %-4d %-16s %s  +$%s  %s\n", item.AccountID, item.Code, status, item.Amount.StringFixed(2), item.Message)
+	}
+	for _, account := range result.Accounts {
+		line := fmt.Sprintf("账号 %-4d %-16s 余额 $%s -> $%s", account.AccountID, account.Username,
+			account.BalanceBefore.StringFixed(2), account.BalanceAfter.StringFixed(2))
+		if account.Error != "" {
+			line += "  (" + account.Error + ")"
+		}
+		fmt.Fprintln(os.Stdout, line)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func parseAccountIDs(raw string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+func readCodes(path string) ([]string, error) {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		reader = f
+	}
+	var codes []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			codes = append(codes, line)
+		}
+	}
+	return codes, scanner.Err()
+}
@@ -1,33 +1,64 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type Config struct {
 	Server   ServerConfig
+	Log      LogConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
 	AES      AESConfig
 	Admin    AdminConfig
+	Proxy    ProxyConfig
+	Upstream UpstreamConfig
+	OIDC     OIDCConfig
 }
 
+// ServerConfig 中 TrustedProxies 为可信反向代理的 IP 或 CIDR，仅来自这些地址的
+// X-Forwarded-For / X-Real-IP 会被用于识别客户端 IP，为空时不信任任何代理。
 type ServerConfig struct {
-	Port int
-	Mode string
+	Port           int
+	Mode           string
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
+// LogConfig 中 Level 为空时 debug 模式使用 debug 级别，其他模式使用 info 级别。
+type LogConfig struct {
+	Level string
+}
+
+// DatabaseConfig 中 Driver 为 sqlite、postgres 或 mysql，sqlite 使用 Path，其他驱动使用 DSN。
+// 连接池配置为 0 时使用 database/sql 的默认值。
 type DatabaseConfig struct {
-	Path string
+	Driver          string
+	Path            string
+	DSN             string
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
 }
 
 type JWTConfig struct {
-	Secret string
-	Expire time.Duration
+	Secret        string
+	Expire        time.Duration
+	RefreshExpire time.Duration `mapstructure:"refresh_expire"`
 }
 
 type AESConfig struct {
@@ -39,27 +70,256 @@ type AdminConfig struct {
 	Password string
 }
 
-var C *Config
+type ProxyConfig struct {
+	DialTimeout           time.Duration `mapstructure:"dial_timeout"`
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"`
+	IdleConnTimeout       time.Duration `mapstructure:"idle_conn_timeout"`
+	MaxIdleConns          int           `mapstructure:"max_idle_conns"`
+	MaxBodySize           int64         `mapstructure:"max_body_size"`
+	ForwardClientIP       bool          `mapstructure:"forward_client_ip"`
+}
+
+type UpstreamConfig struct {
+	BaseURL string `mapstructure:"base_url"`
+}
 
+// OIDCConfig 为单点登录配置，*_groups 为映射到对应角色的身份提供方用户组，多个组匹配时取最高角色。
+type OIDCConfig struct {
+	Enabled        bool
+	Issuer         string
+	ClientID       string `mapstructure:"client_id"`
+	ClientSecret   string `mapstructure:"client_secret"`
+	RedirectURL    string `mapstructure:"redirect_url"`
+	Scopes         []string
+	UsernameClaim  string   `mapstructure:"username_claim"`
+	GroupsClaim    string   `mapstructure:"groups_claim"`
+	AdminGroups    []string `mapstructure:"admin_groups"`
+	OperatorGroups []string `mapstructure:"operator_groups"`
+	ViewerGroups   []string `mapstructure:"viewer_groups"`
+	DefaultRole    string   `mapstructure:"default_role"`
+	AutoProvision  bool     `mapstructure:"auto_provision"`
+	LinkExisting   bool     `mapstructure:"link_existing"`
+	LocalLogin     bool     `mapstructure:"local_login"`
+	FrontendURL    string   `mapstructure:"frontend_url"`
+}
+
+// EnvPrefix 为环境变量前缀，配置项中的 . 替换为 _，例如 server.port 对应 ANYROUTER_SERVER_PORT。
+const EnvPrefix = "ANYROUTER"
+
+var (
+	current     atomic.Pointer[Config]
+	subscribers []func(old, new *Config)
+	subMu       sync.Mutex
+)
+
+// Get 返回当前生效的配置，配置文件热加载后返回新的配置，调用方不应修改返回值。
+func Get() *Config {
+	return current.Load()
+}
+
+// Set 替换当前配置，供命令行工具与测试使用，不会通知订阅者。
+func Set(c *Config) {
+	current.Store(c)
+}
+
+// Load 依次读取默认值、config.yaml（可不存在）与 ANYROUTER_ 开头的环境变量，环境变量优先，并校验结果。
 func Load() error {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("./backend")
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	bindEnvs(reflect.TypeOf(Config{}), "")
+	setDefaults()
 
 	if err := viper.ReadInConfig(); err != nil {
-		return err
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return err
+		}
 	}
 
-	C = &Config{}
-	if err := viper.Unmarshal(C); err != nil {
+	cfg, err := decode()
+	if err != nil {
 		return err
 	}
+	current.Store(cfg)
+	return nil
+}
+
+func decode() (*Config, error) {
+	cfg := &Config{}
+	if err := viper.Unmarshal(cfg); err != nil {
+		return nil, err
+	}
 
-	C.JWT.Secret = expandEnv(C.JWT.Secret)
-	C.AES.Key = expandEnv(C.AES.Key)
+	cfg.JWT.Secret = expandEnv(cfg.JWT.Secret)
+	cfg.AES.Key = expandEnv(cfg.AES.Key)
+	cfg.OIDC.ClientSecret = expandEnv(cfg.OIDC.ClientSecret)
+	cfg.Database.DSN = expandEnv(cfg.Database.DSN)
 
-	return nil
+	return cfg, cfg.Validate()
+}
+
+// Subscribe 注册配置变更回调，配置文件热加载成功后按注册顺序调用。
+func Subscribe(fn func(old, new *Config)) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// Watch 监听配置文件变更并热加载，未使用配置文件时不做任何事。
+// 新配置校验失败时保留当前配置；server.port、server.trusted_proxies、database 与 aes.key 需重启后生效。
+func Watch() {
+	if viper.ConfigFileUsed() == "" {
+		return
+	}
+	viper.OnConfigChange(func(event fsnotify.Event) {
+		reload(event.Name)
+	})
+	viper.WatchConfig()
+}
+
+func reload(file string) {
+	subMu.Lock()
+	defer subMu.Unlock()
+
+	old := current.Load()
+	cfg, err := decode()
+	if err != nil {
+		zap.L().Error("配置文件有误，保留当前配置", zap.String("file", file), zap.Error(err))
+		return
+	}
+	for _, item := range []struct {
+		key     string
+		changed bool
+	}{
+		{"server.port", cfg.Server.Port != old.Server.Port},
+		{"server.trusted_proxies", !slices.Equal(cfg.Server.TrustedProxies, old.Server.TrustedProxies)},
+		{"database", cfg.Database != old.Database},
+		{"aes.key", cfg.AES.Key != old.AES.Key},
+	} {
+		if item.changed {
+			zap.L().Warn("配置项需重启后生效", zap.String("key", item.key))
+		}
+	}
+	cfg.Server.Port = old.Server.Port
+	cfg.Server.TrustedProxies = old.Server.TrustedProxies
+	cfg.Database = old.Database
+	cfg.AES.Key = old.AES.Key
+
+	current.Store(cfg)
+	zap.L().Info("配置文件已重新加载", zap.String("file", file))
+	for _, fn := range subscribers {
+		fn(old, cfg)
+	}
+}
+
+// bindEnvs 为每个配置项绑定环境变量，使配置文件中不存在的配置项也能通过环境变量设置。
+func bindEnvs(t reflect.Type, prefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			bindEnvs(field.Type, key+".")
+			continue
+		}
+		_ = viper.BindEnv(key)
+	}
+}
+
+// Validate 校验配置，release 模式下 InsecureSettings 返回的问题同样视为错误。
+func (c *Config) Validate() error {
+	var errs []error
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("server.mode 应为 debug、release 或 test，当前为 %q", c.Server.Mode))
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port 无效: %d", c.Server.Port))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("server.trusted_proxies 中的 %q 不是有效的 IP 或 CIDR", proxy))
+			}
+		}
+	}
+	switch c.Database.Driver {
+	case "sqlite":
+		if c.Database.Path == "" {
+			errs = append(errs, errors.New("database.path 不能为空"))
+		}
+	case "postgres", "mysql":
+		if c.Database.DSN == "" {
+			errs = append(errs, fmt.Errorf("使用 %s 时 database.dsn 不能为空", c.Database.Driver))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("database.driver 应为 sqlite、postgres 或 mysql，当前为 %q", c.Database.Driver))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database 连接池配置不能为负数"))
+	}
+	if c.Log.Level != "" {
+		if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+			errs = append(errs, fmt.Errorf("log.level 无效: %q", c.Log.Level))
+		}
+	}
+	if c.JWT.Secret == "" {
+		errs = append(errs, errors.New("jwt.secret 不能为空"))
+	}
+	if c.JWT.Expire <= 0 || c.JWT.RefreshExpire <= 0 {
+		errs = append(errs, errors.New("jwt.expire 与 jwt.refresh_expire 必须大于 0"))
+	}
+	if c.AES.Key == "" {
+		errs = append(errs, errors.New("aes.key 不能为空"))
+	}
+	if c.Admin.Username == "" || c.Admin.Password == "" {
+		errs = append(errs, errors.New("admin.username 与 admin.password 不能为空"))
+	}
+	if c.OIDC.Enabled && (c.OIDC.Issuer == "" || c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "") {
+		errs = append(errs, errors.New("启用 oidc 时 issuer、client_id 与 redirect_url 不能为空"))
+	}
+	if c.Server.Mode == "release" {
+		for _, issue := range c.InsecureSettings() {
+			errs = append(errs, errors.New(issue))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InsecureSettings 返回不应在生产环境使用的配置，如示例配置中的占位符与默认管理员密码。
+func (c *Config) InsecureSettings() []string {
+	var issues []string
+	for _, item := range []struct {
+		key   string
+		value string
+	}{
+		{"jwt.secret", c.JWT.Secret},
+		{"aes.key", c.AES.Key},
+		{"oidc.client_secret", c.OIDC.ClientSecret},
+		{"database.dsn", c.Database.DSN},
+	} {
+		if isPlaceholder(item.value) {
+			issues = append(issues, fmt.Sprintf("%s 仍为示例占位符 %s", item.key, item.value))
+		}
+	}
+	if c.Admin.Password == defaultAdminPassword {
+		issues = append(issues, "admin.password 仍为默认密码 "+defaultAdminPassword)
+	}
+	return issues
+}
+
+func isPlaceholder(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "<") && strings.HasSuffix(value, ">")
 }
 
 func expandEnv(s string) string {
@@ -76,3 +336,30 @@ func expandEnv(s string) string {
 	}
 	return s
 }
+
+// defaultAdminPassword 为示例配置中的管理员密码，release 模式下禁止使用。
+const defaultAdminPassword = "admin123"
+
+func setDefaults() {
+	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("server.mode", "release")
+	viper.SetDefault("database.driver", "sqlite")
+	viper.SetDefault("database.path", "./data/app.db")
+	viper.SetDefault("database.max_idle_conns", 2)
+	viper.SetDefault("database.conn_max_lifetime", 30*time.Minute)
+	viper.SetDefault("admin.username", "admin")
+	viper.SetDefault("jwt.expire", 15*time.Minute)
+	viper.SetDefault("jwt.refresh_expire", 7*24*time.Hour)
+	viper.SetDefault("proxy.dial_timeout", 10*time.Second)
+	viper.SetDefault("proxy.response_header_timeout", 60*time.Second)
+	viper.SetDefault("proxy.idle_conn_timeout", 90*time.Second)
+	viper.SetDefault("proxy.max_idle_conns", 100)
+	viper.SetDefault("proxy.max_body_size", 10<<20)
+	viper.SetDefault("proxy.forward_client_ip", false)
+	viper.SetDefault("upstream.base_url", "https://anyrouter.top")
+	viper.SetDefault("oidc.scopes", []string{"openid", "profile", "email", "groups"})
+	viper.SetDefault("oidc.username_claim", "preferred_username")
+	viper.SetDefault("oidc.groups_claim", "groups")
+	viper.SetDefault("oidc.auto_provision", true)
+	viper.SetDefault("oidc.local_login", true)
+}
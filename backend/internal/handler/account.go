@@ -24,19 +24,33 @@ type UpdateAccountStatusRequest struct {
 	Status *int `json:"status" binding:"required" example:"1"`
 }
 
+type UpdateAccountNetworkRequest struct {
+	ProxyURL  string `json:"proxy_url" example:"socks5://127.0.0.1:1080"`
+	ProfileID *uint  `json:"profile_id" example:"1"`
+}
+
+type TransferAccountRequest struct {
+	OwnerID uint `json:"owner_id" binding:"required" example:"2"`
+}
+
 type VerifyRequest struct {
 	Session string `json:"session" binding:"required" example:"base64-session-cookie"`
 }
 
 // ListAccounts 账号列表
-// @Summary 获取所有账号
+// @Summary 获取当前用户可见的账号
+// @Description 管理员可见全部账号，其他用户可见自己及同团队成员的账号
 // @Tags 账号管理
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} response.Response{data=[]model.Account}
 // @Router /accounts [get]
 func ListAccounts(c *gin.Context) {
-	accounts, err := service.ListAccounts()
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	accounts, err := service.ListAccounts(scope)
 	if err != nil {
 		response.Error(c, 500, "获取账号失败")
 		return
@@ -60,7 +74,7 @@ func CreateAccount(c *gin.Context) {
 		return
 	}
 
-	account, err := service.CreateAccount(req.Session)
+	account, err := service.CreateAccount(c.GetUint("user_id"), req.Session)
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidSession) {
 			response.Error(c, 400, err.Error())
@@ -89,6 +103,9 @@ func UpdateAccount(c *gin.Context) {
 		response.Error(c, 400, "账号ID无效")
 		return
 	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
 
 	var req UpdateAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
@@ -128,6 +145,9 @@ func UpdateAccountStatus(c *gin.Context) {
 		response.Error(c, 400, "账号ID无效")
 		return
 	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
 
 	var req UpdateAccountStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -152,6 +172,49 @@ func UpdateAccountStatus(c *gin.Context) {
 	response.Success(c, account)
 }
 
+// UpdateAccountNetwork 更新账号出口代理与指纹
+// @Summary 设置账号的出口代理与浏览器指纹配置
+// @Description proxy_url 为空表示直连，profile_id 为空表示使用默认指纹
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Param request body UpdateAccountNetworkRequest true "网络参数"
+// @Success 200 {object} response.Response{data=model.Account}
+// @Router /accounts/{id}/network [put]
+func UpdateAccountNetwork(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "账号ID无效")
+		return
+	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
+
+	var req UpdateAccountNetworkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+
+	account, err := service.UpdateAccountNetwork(uint(id), req.ProxyURL, req.ProfileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "账号或指纹配置不存在")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidProxyURL) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "更新失败")
+		return
+	}
+	response.Success(c, account)
+}
+
 // DeleteAccount 删除账号
 // @Summary 删除账号
 // @Tags 账号管理
@@ -166,6 +229,9 @@ func DeleteAccount(c *gin.Context) {
 		response.Error(c, 400, "账号ID无效")
 		return
 	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
 	if err := service.DeleteAccount(uint(id)); err != nil {
 		response.Error(c, 500, "删除失败")
 		return
@@ -187,6 +253,9 @@ func CheckinAccount(c *gin.Context) {
 		response.Error(c, 400, "账号ID无效")
 		return
 	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
 	success, result := service.CheckinAccount(uint(id))
 	response.Success(c, gin.H{
 		"success": success,
@@ -208,6 +277,9 @@ func RefreshAccount(c *gin.Context) {
 		response.Error(c, 400, "账号ID无效")
 		return
 	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
 
 	account, err := service.RefreshAccount(uint(id))
 	if err != nil {
@@ -223,6 +295,11 @@ func RefreshAccount(c *gin.Context) {
 			response.Error(c, 400, err.Error())
 			return
 		}
+		var challengeErr *service.ChallengeError
+		if errors.As(err, &challengeErr) {
+			response.Error(c, 502, err.Error())
+			return
+		}
 		response.Error(c, 500, "刷新失败")
 		return
 	}
@@ -253,3 +330,39 @@ func VerifyAccount(c *gin.Context) {
 
 	response.Success(c, info)
 }
+
+// TransferAccount 转交账号
+// @Summary 将账号转交给其他用户
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Param request body TransferAccountRequest true "新所有者"
+// @Success 200 {object} response.Response{data=model.Account}
+// @Router /accounts/{id}/owner [put]
+func TransferAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "账号ID无效")
+		return
+	}
+	var req TransferAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	account, err := service.TransferAccount(uint(id), req.OwnerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			response.Error(c, 400, err.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.Error(c, 404, "账号不存在")
+		default:
+			response.Error(c, 500, "转交失败")
+		}
+		return
+	}
+	response.Success(c, account)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAuditEvents 审计日志
+// @Summary 查询修改类操作的审计记录
+// @Description 记录所有登录后的 POST、PUT、DELETE 请求（含经 /anyrouter 代理转发的请求）；before 与 after 为变更字段的 JSON，密码、令牌、密钥等字段及 URL 中的用户名密码已脱敏；
+// @Description code 为响应中的 code，0 表示成功，代理请求为上游 HTTP 状态码
+// @Tags 审计
+// @Produce json
+// @Security BearerAuth
+// @Param actor query string false "操作人用户名"
+// @Param action query string false "操作（模糊匹配），如 DELETE /accounts"
+// @Param target_type query string false "目标类型，如 accounts、cron、config、users、anyrouter"
+// @Param target_id query string false "目标ID"
+// @Param start_date query string false "开始日期 YYYY-MM-DD"
+// @Param end_date query string false "结束日期 YYYY-MM-DD"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50)
+// @Success 200 {object} response.Response{data=service.AuditEventPage}
+// @Router /audit [get]
+func ListAuditEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	events, err := service.ListAuditEvents(service.AuditQuery{
+		ActorName:  c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		StartDate:  c.Query("start_date"),
+		EndDate:    c.Query("end_date"),
+	}, page, size)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAuditQuery) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "获取审计记录失败")
+		return
+	}
+	response.Success(c, events)
+}
@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
 	"anyrouter-checkin/internal/service"
 	"anyrouter-checkin/pkg/response"
 
@@ -19,11 +23,14 @@ type ChangePasswordRequest struct {
 
 // Login 用户登录
 // @Summary 用户登录
+// @Description 开启两步验证的用户返回 two_factor_required 与 challenge_token，需调用 /auth/login/2fa 提交动态码。
+// @Description 同一用户名连续失败 5 次或同一 IP 失败 20 次后锁定，返回 code 429 与 Retry-After 响应头；
+// @Description 启用单点登录且关闭 oidc.local_login 时返回 code 403
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "登录参数"
-// @Success 200 {object} response.Response{data=map[string]string}
+// @Success 200 {object} response.Response{data=service.LoginResult}
 // @Router /auth/login [post]
 func Login(c *gin.Context) {
 	var req LoginRequest
@@ -32,13 +39,13 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token, err := service.Login(req.Username, req.Password)
+	result, err := service.Login(req.Username, req.Password, loginClient(c))
 	if err != nil {
-		response.Error(c, 401, err.Error())
+		respondLoginError(c, err)
 		return
 	}
 
-	response.Success(c, gin.H{"token": token})
+	response.Success(c, result)
 }
 
 // Profile 获取当前用户信息
@@ -59,20 +66,30 @@ func Profile(c *gin.Context) {
 		response.Unauthorized(c)
 		return
 	}
+	user, err := service.GetUser(c.GetUint("user_id"))
+	if err != nil {
+		response.Unauthorized(c)
+		return
+	}
 	response.Success(c, gin.H{
-		"user_id":  userID,
-		"username": username,
+		"user_id":          userID,
+		"username":         username,
+		"role":             user.Role,
+		"team_id":          user.TeamID,
+		"telegram_chat_id": user.TelegramChatID,
+		"totp_enabled":     user.TOTPEnabled,
 	})
 }
 
 // ChangePassword 修改密码
 // @Summary 修改当前用户密码
+// @Description 修改后该用户的全部会话失效，响应中返回当前客户端的新令牌
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body ChangePasswordRequest true "密码参数"
-// @Success 200 {object} response.Response
+// @Success 200 {object} response.Response{data=service.LoginResult}
 // @Router /auth/password [put]
 func ChangePassword(c *gin.Context) {
 	var req ChangePasswordRequest
@@ -91,10 +108,52 @@ func ChangePassword(c *gin.Context) {
 		response.Error(c, 400, "用户信息异常")
 		return
 	}
-	if err := service.ChangePassword(id, req.OldPassword, req.NewPassword); err != nil {
+	result, err := service.ChangePassword(id, req.OldPassword, req.NewPassword, loginClient(c))
+	if err != nil {
 		response.Error(c, 400, err.Error())
 		return
 	}
 
-	response.Success(c, nil)
+	response.Success(c, result)
+}
+
+// ListLoginAttempts 登录失败记录
+// @Summary 查询最近的登录失败记录
+// @Description 最多返回 200 条，记录保留 30 天
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Param username query string false "用户名"
+// @Param ip query string false "客户端 IP"
+// @Success 200 {object} response.Response{data=[]model.LoginAttempt}
+// @Router /login-attempts [get]
+func ListLoginAttempts(c *gin.Context) {
+	attempts, err := service.ListLoginAttempts(c.Query("username"), c.Query("ip"), 200)
+	if err != nil {
+		response.Error(c, 500, "获取登录失败记录失败")
+		return
+	}
+	response.Success(c, attempts)
+}
+
+func loginClient(c *gin.Context) service.LoginClient {
+	return service.LoginClient{IP: c.ClientIP(), UserAgent: c.GetHeader("User-Agent")}
+}
+
+// respondLoginError 将登录失败映射为响应，锁定时附带 Retry-After 响应头（秒）。
+func respondLoginError(c *gin.Context, err error) {
+	var lockedErr *service.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		response.Error(c, 429, err.Error())
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrUserDisabled),
+		errors.Is(err, service.ErrTwoFactorCode), errors.Is(err, service.ErrTwoFactorChallenge),
+		errors.Is(err, service.ErrInvalidRefreshToken):
+		response.Error(c, 401, err.Error())
+	case errors.Is(err, service.ErrLocalLoginDisabled):
+		response.Error(c, 403, err.Error())
+	default:
+		response.Error(c, 500, "登录失败")
+	}
 }
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxConfigImportSize = 1 << 20
+
+type RollbackConfigRequest struct {
+	VersionID uint   `json:"version_id" binding:"required" example:"12"`
+	Scope     string `json:"scope" example:"key" enums:"key,category"`
+}
+
+type ExportConfigsRequest struct {
+	Format         string `json:"format" example:"yaml" enums:"yaml,json"`
+	IncludeSecrets bool   `json:"include_secrets" example:"false"`
+	Passphrase     string `json:"passphrase" example:"export-passphrase"`
+}
+
+// ListConfigVersions 配置变更历史
+// @Summary 查询系统配置的变更历史
+// @Description 每次修改、回滚与导入都会记录变更前后的值，敏感配置以 ****** 显示；source 为 update、rollback 或 import
+// @Tags 系统配置
+// @Produce json
+// @Security BearerAuth
+// @Param key query string false "配置项，如 telegram.template"
+// @Param category query string false "配置分类"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50)
+// @Success 200 {object} response.Response{data=service.ConfigVersionPage}
+// @Router /config/history [get]
+func ListConfigVersions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	versions, err := service.ListConfigVersions(c.Query("key"), c.Query("category"), page, size)
+	if err != nil {
+		response.Error(c, 500, "获取配置历史失败")
+		return
+	}
+	response.Success(c, versions)
+}
+
+// RollbackConfig 回滚配置
+// @Summary 撤销指定变更及其之后的修改
+// @Description scope 为 key（默认）时只恢复该变更的配置项，为 category 时恢复同分类下全部配置项到该变更之前的值；回滚本身也会记录在历史中
+// @Tags 系统配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RollbackConfigRequest true "回滚参数"
+// @Success 200 {object} response.Response{data=[]string}
+// @Router /config/rollback [post]
+func RollbackConfig(c *gin.Context) {
+	var req RollbackConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	keys, err := service.RollbackConfig(req.VersionID, req.Scope, configActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.Error(c, 404, "变更记录不存在")
+		case errors.Is(err, service.ErrInvalidRollbackScope):
+			response.Error(c, 400, err.Error())
+		default:
+			response.Error(c, 500, "回滚配置失败")
+		}
+		return
+	}
+	response.Success(c, keys)
+}
+
+// ExportConfigs 导出配置
+// @Summary 导出全部系统配置为 YAML 或 JSON 文件
+// @Description 默认不包含敏感配置；include_secrets 为 true 时需提供至少 8 位的口令，敏感配置以该口令加密，导入时需提供相同口令
+// @Tags 系统配置
+// @Accept json
+// @Produce application/x-yaml,application/json
+// @Security BearerAuth
+// @Param request body ExportConfigsRequest true "导出参数"
+// @Success 200 {file} file
+// @Router /config/export [post]
+func ExportConfigs(c *gin.Context) {
+	var req ExportConfigsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	if req.Format == "" {
+		req.Format = "yaml"
+	}
+	data, err := service.ExportConfigs(req.Format, req.IncludeSecrets, req.Passphrase)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidConfigExport) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "导出配置失败")
+		return
+	}
+	contentType := "application/x-yaml"
+	if req.Format == "json" {
+		contentType = "application/json"
+	}
+	filename := "anyrouter-config-" + carbon.Now().ToShortDateTimeString() + "." + req.Format
+	response.Attachment(c, filename, contentType, data)
+}
+
+// ImportConfigs 导入配置
+// @Summary 导入由导出接口生成的配置文件
+// @Description 全部配置项校验通过后才会保存，校验失败时返回 code 400，data.errors 为各字段的错误信息；导入会记录在配置历史中，可通过回滚撤销
+// @Tags 系统配置
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "YAML 或 JSON 配置文件"
+// @Param passphrase formData string false "导出时使用的口令，文件包含加密的敏感配置时必填"
+// @Success 200 {object} response.Response{data=map[string]int}
+// @Router /config/import [post]
+func ImportConfigs(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, 400, "请上传配置文件")
+		return
+	}
+	if header.Size > maxConfigImportSize {
+		response.Error(c, 400, "配置文件过大")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.Error(c, 400, "读取配置文件失败")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxConfigImportSize))
+	if err != nil {
+		response.Error(c, 400, "读取配置文件失败")
+		return
+	}
+
+	count, err := service.ImportConfigs(data, c.PostForm("passphrase"), configActor(c))
+	if err != nil {
+		var validationErr *service.ConfigValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			response.ErrorWithData(c, 400, err.Error(), gin.H{"errors": validationErr.Fields})
+		case errors.Is(err, service.ErrInvalidConfigImport):
+			response.Error(c, 400, err.Error())
+		default:
+			response.Error(c, 500, "导入配置失败")
+		}
+		return
+	}
+	response.Success(c, gin.H{"imported": count})
+}
+
+func configActor(c *gin.Context) service.ConfigActor {
+	return service.ConfigActor{ID: c.GetUint("user_id"), Name: c.GetString("username")}
+}
@@ -15,20 +15,24 @@ import (
 type CronRequest struct {
 	Name       string `json:"name" binding:"required" example:"每日签到"`
 	CronExpr   string `json:"cron_expr" binding:"required" example:"0 8 * * *"`
-	TaskType   string `json:"task_type" example:"checkin"`
+	TaskType   string `json:"task_type" enums:"checkin,usage_sync" example:"checkin"`
 	AccountIDs string `json:"account_ids" example:"[1,2]"`
 	Status     int    `json:"status" example:"1"`
 }
 
 // ListCronTasks 定时任务列表
-// @Summary 获取所有定时任务
+// @Summary 获取当前用户可见的定时任务
 // @Tags 定时任务
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} response.Response{data=[]model.CronTask}
 // @Router /cron [get]
 func ListCronTasks(c *gin.Context) {
-	tasks, err := service.ListCronTasks()
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	tasks, err := service.ListCronTasks(scope)
 	if err != nil {
 		response.Error(c, 500, "获取任务失败")
 		return
@@ -52,16 +56,25 @@ func CreateCronTask(c *gin.Context) {
 		return
 	}
 
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
 	task := model.CronTask{
+		OwnerID:    c.GetUint("user_id"),
 		Name:       req.Name,
 		CronExpr:   req.CronExpr,
-		TaskType:   "checkin",
+		TaskType:   req.TaskType,
 		AccountIDs: req.AccountIDs,
 		Status:     1,
 	}
 
-	created, err := service.CreateCronTask(task)
+	created, err := service.CreateCronTask(scope, task)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidTaskType) || errors.Is(err, service.ErrInvalidTaskAccounts) {
+			response.Error(c, 400, err.Error())
+			return
+		}
 		response.Error(c, 500, "创建失败")
 		return
 	}
@@ -92,10 +105,14 @@ func UpdateCronTask(c *gin.Context) {
 		return
 	}
 
-	updated, err := service.UpdateCronTask(uint(id), model.CronTask{
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	updated, err := service.UpdateCronTask(scope, uint(id), model.CronTask{
 		Name:       req.Name,
 		CronExpr:   req.CronExpr,
-		TaskType:   "checkin",
+		TaskType:   req.TaskType,
 		AccountIDs: req.AccountIDs,
 		Status:     req.Status,
 	})
@@ -104,6 +121,10 @@ func UpdateCronTask(c *gin.Context) {
 			response.Error(c, 404, "任务不存在")
 			return
 		}
+		if errors.Is(err, service.ErrInvalidTaskType) || errors.Is(err, service.ErrInvalidTaskAccounts) {
+			response.Error(c, 400, err.Error())
+			return
+		}
 		response.Error(c, 500, "更新失败")
 		return
 	}
@@ -124,7 +145,15 @@ func DeleteCronTask(c *gin.Context) {
 		response.Error(c, 400, "任务ID无效")
 		return
 	}
-	if err := service.DeleteCronTask(uint(id)); err != nil {
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	if err := service.DeleteCronTask(scope, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "任务不存在")
+			return
+		}
 		response.Error(c, 500, "删除失败")
 		return
 	}
@@ -145,6 +174,18 @@ func TriggerCronTask(c *gin.Context) {
 		response.Error(c, 400, "任务ID无效")
 		return
 	}
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	if err := service.CheckCronTaskAccess(scope, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "任务不存在")
+			return
+		}
+		response.Error(c, 500, "获取任务失败")
+		return
+	}
 	go service.ExecuteTask(uint(id))
 	response.Success(c, gin.H{"message": "任务已触发"})
 }
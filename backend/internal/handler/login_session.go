@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"arr_xxx"`
+}
+
+// RefreshToken 刷新登录令牌
+// @Summary 使用刷新令牌换取新的访问令牌
+// @Description 刷新令牌每次使用后轮换，需保存响应中新的 refresh_token；已轮换的旧刷新令牌再次使用会吊销整个会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "刷新令牌"
+// @Success 200 {object} response.Response{data=service.LoginResult}
+// @Router /auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	result, err := service.RefreshLoginSession(req.RefreshToken, loginClient(c))
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// Logout 退出登录
+// @Summary 吊销当前会话
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	if err := service.RevokeLoginSession(c.GetUint("user_id"), c.GetUint("session_id")); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 500, "退出失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ListLoginSessions 登录会话列表
+// @Summary 获取当前用户未过期的登录会话
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]service.LoginSessionInfo}
+// @Router /auth/sessions [get]
+func ListLoginSessions(c *gin.Context) {
+	sessions, err := service.ListLoginSessions(c.GetUint("user_id"), c.GetUint("session_id"))
+	if err != nil {
+		response.Error(c, 500, "获取会话失败")
+		return
+	}
+	response.Success(c, sessions)
+}
+
+// RevokeLoginSession 吊销登录会话
+// @Summary 吊销指定的登录会话
+// @Description 该会话的访问令牌与刷新令牌立即失效
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "会话ID"
+// @Success 200 {object} response.Response
+// @Router /auth/sessions/{id} [delete]
+func RevokeLoginSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "会话ID无效")
+		return
+	}
+	if err := service.RevokeLoginSession(c.GetUint("user_id"), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "会话不存在")
+			return
+		}
+		response.Error(c, 500, "吊销失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RevokeOtherLoginSessions 吊销其他会话
+// @Summary 吊销当前会话以外的全部登录会话
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Router /auth/sessions [delete]
+func RevokeOtherLoginSessions(c *gin.Context) {
+	if err := service.RevokeOtherLoginSessions(c.GetUint("user_id"), c.GetUint("session_id")); err != nil {
+		response.Error(c, 500, "吊销失败")
+		return
+	}
+	response.Success(c, nil)
+}
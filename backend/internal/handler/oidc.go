@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuthMethods 登录方式
+// @Summary 获取可用的登录方式
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} response.Response{data=service.AuthMethods}
+// @Router /auth/methods [get]
+func AuthMethods(c *gin.Context) {
+	response.Success(c, service.GetAuthMethods())
+}
+
+// oidcStateCookie 保存发起单点登录时加密的 state、nonce 与 code_verifier，回调时 state 需与查询参数一致。
+const (
+	oidcStateCookie     = "anyrouter_oidc_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+// OIDCLogin 单点登录
+// @Summary 跳转到身份提供方登录
+// @Description 使用授权码流程（PKCE），登录完成后身份提供方回调 /auth/oidc/callback；
+// @Description state 与 PKCE 参数加密后写入 HttpOnly Cookie，回调须由同一浏览器发起
+// @Tags 认证
+// @Param login_hint query string false "传递给身份提供方的登录提示"
+// @Success 302
+// @Router /auth/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	authURL, sealed, err := service.StartOIDCLogin(c.Query("login_hint"))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCDisabled) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 502, err.Error())
+		return
+	}
+	setOIDCStateCookie(c, sealed, service.OIDCStateTTLSeconds)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 单点登录回调
+// @Summary 身份提供方登录完成后的回调
+// @Description 成功时跳转到前端 /login 页，并在 URL 片段中携带 token、refresh_token 与 expires_in；失败时携带 error。
+// @Description state 与发起登录时写入的 Cookie 不一致时视为登录 CSRF，返回 error
+// @Tags 认证
+// @Param code query string false "授权码"
+// @Param state query string true "state"
+// @Success 302
+// @Router /auth/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	sealed, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		zap.L().Warn("身份提供方拒绝授权", zap.String("error", idpErr), zap.String("description", c.Query("error_description")))
+		redirectOIDCResult(c, url.Values{"error": {service.ErrOIDCProviderFailure.Error()}})
+		return
+	}
+	result, err := service.CompleteOIDCLogin(sealed, c.Query("state"), c.Query("code"), loginClient(c))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCState) {
+			zap.L().Warn("OIDC 回调的 state 与发起登录的浏览器不一致或已过期", zap.String("ip", c.ClientIP()))
+		}
+		redirectOIDCResult(c, url.Values{"error": {err.Error()}})
+		return
+	}
+	redirectOIDCResult(c, url.Values{
+		"token":         {result.Token},
+		"refresh_token": {result.RefreshToken},
+		"expires_in":    {strconv.FormatInt(result.ExpiresIn, 10)},
+	})
+}
+
+// setOIDCStateCookie 写入或清除（maxAge 为 -1）state Cookie。SameSite=Lax 使身份提供方跳转回来的
+// 顶层 GET 请求仍会携带该 Cookie；回调地址为 https 时仅通过 https 发送。
+func setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := strings.HasPrefix(config.Get().OIDC.RedirectURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcStateCookiePath, "", secure, true)
+}
+
+// redirectOIDCResult 跳转回前端登录页，结果放在 URL 片段中，不会发送到服务器或写入访问日志。
+func redirectOIDCResult(c *gin.Context, values url.Values) {
+	target := strings.TrimRight(config.Get().OIDC.FrontendURL, "/") + "/login#" + values.Encode()
+	c.Redirect(http.StatusFound, target)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PersonalTokenRequest struct {
+	Name          string   `json:"name" binding:"required" example:"ci"`
+	Scopes        []string `json:"scopes" binding:"required" example:"read,checkin"`
+	ExpiresInDays int      `json:"expires_in_days" example:"90"`
+}
+
+// ListPersonalTokens 个人访问令牌列表
+// @Summary 获取当前用户的个人访问令牌
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.PersonalToken}
+// @Router /auth/tokens [get]
+func ListPersonalTokens(c *gin.Context) {
+	tokens, err := service.ListPersonalTokens(c.GetUint("user_id"))
+	if err != nil {
+		response.Error(c, 500, "获取令牌失败")
+		return
+	}
+	response.Success(c, tokens)
+}
+
+// CreatePersonalToken 创建个人访问令牌
+// @Summary 创建个人访问令牌
+// @Description scopes 可选 read、checkin、admin，分别对应 viewer、operator、admin 的权限且不能超出当前角色；expires_in_days 为 0 表示永不过期。完整令牌仅在创建时返回一次，使用方式与登录令牌相同（Authorization: Bearer）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PersonalTokenRequest true "令牌参数"
+// @Success 200 {object} response.Response{data=service.PersonalTokenCreated}
+// @Router /auth/tokens [post]
+func CreatePersonalToken(c *gin.Context) {
+	var req PersonalTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	token, err := service.CreatePersonalToken(c.GetUint("user_id"), req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPersonalToken) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "创建失败")
+		return
+	}
+	response.Success(c, token)
+}
+
+// RevokePersonalToken 吊销个人访问令牌
+// @Summary 吊销个人访问令牌
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "令牌ID"
+// @Success 200 {object} response.Response
+// @Router /auth/tokens/{id} [delete]
+func RevokePersonalToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "令牌ID无效")
+		return
+	}
+	if err := service.RevokePersonalToken(c.GetUint("user_id"), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "令牌不存在")
+			return
+		}
+		response.Error(c, 500, "吊销失败")
+		return
+	}
+	response.Success(c, nil)
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BrowserProfileRequest struct {
+	Name            string `json:"name" binding:"required" example:"Windows Chrome"`
+	UserAgent       string `json:"user_agent" binding:"required" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"`
+	SecChUa         string `json:"sec_ch_ua" example:"\"Chromium\";v=\"144\", \"Google Chrome\";v=\"144\""`
+	SecChUaMobile   string `json:"sec_ch_ua_mobile" example:"?0"`
+	SecChUaPlatform string `json:"sec_ch_ua_platform" example:"\"Windows\""`
+	AcceptLanguage  string `json:"accept_language" example:"zh-CN,zh;q=0.9"`
+}
+
+func (r BrowserProfileRequest) toModel() model.BrowserProfile {
+	return model.BrowserProfile{
+		Name:            r.Name,
+		UserAgent:       r.UserAgent,
+		SecChUa:         r.SecChUa,
+		SecChUaMobile:   r.SecChUaMobile,
+		SecChUaPlatform: r.SecChUaPlatform,
+		AcceptLanguage:  r.AcceptLanguage,
+	}
+}
+
+// ListBrowserProfiles 指纹配置列表
+// @Summary 获取所有浏览器指纹配置
+// @Tags 指纹配置
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.BrowserProfile}
+// @Router /profiles [get]
+func ListBrowserProfiles(c *gin.Context) {
+	profiles, err := service.ListBrowserProfiles()
+	if err != nil {
+		response.Error(c, 500, "获取指纹配置失败")
+		return
+	}
+	response.Success(c, profiles)
+}
+
+// CreateBrowserProfile 创建指纹配置
+// @Summary 创建浏览器指纹配置
+// @Tags 指纹配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BrowserProfileRequest true "指纹参数"
+// @Success 200 {object} response.Response{data=model.BrowserProfile}
+// @Router /profiles [post]
+func CreateBrowserProfile(c *gin.Context) {
+	var req BrowserProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+
+	profile, err := service.CreateBrowserProfile(req.toModel())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidProfile) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "创建失败")
+		return
+	}
+	response.Success(c, profile)
+}
+
+// UpdateBrowserProfile 更新指纹配置
+// @Summary 更新浏览器指纹配置
+// @Tags 指纹配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "指纹配置ID"
+// @Param request body BrowserProfileRequest true "指纹参数"
+// @Success 200 {object} response.Response{data=model.BrowserProfile}
+// @Router /profiles/{id} [put]
+func UpdateBrowserProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "指纹配置ID无效")
+		return
+	}
+
+	var req BrowserProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+
+	profile, err := service.UpdateBrowserProfile(uint(id), req.toModel())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "指纹配置不存在")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidProfile) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "更新失败")
+		return
+	}
+	response.Success(c, profile)
+}
+
+// DeleteBrowserProfile 删除指纹配置
+// @Summary 删除浏览器指纹配置
+// @Tags 指纹配置
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "指纹配置ID"
+// @Success 200 {object} response.Response
+// @Router /profiles/{id} [delete]
+func DeleteBrowserProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "指纹配置ID无效")
+		return
+	}
+	if err := service.DeleteBrowserProfile(uint(id)); err != nil {
+		if errors.Is(err, service.ErrProfileInUse) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "删除失败")
+		return
+	}
+	response.Success(c, nil)
+}
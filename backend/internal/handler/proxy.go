@@ -1,124 +1,177 @@
 package handler
 
 import (
-	"io"
+	"errors"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"anyrouter-checkin/internal/config"
 	"anyrouter-checkin/internal/service"
 	"anyrouter-checkin/pkg/response"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 const (
-	anyRouterBaseURL = "https://anyrouter.top"
-	proxyTimeout     = 30 * time.Second
+	proxyAccountHeader = "X-Account-ID"
+	proxyFlushInterval = 100 * time.Millisecond
 )
 
+// proxyPassHeaders 为允许从调用方透传到上游的请求头，其余（含本系统的 Authorization）一律丢弃。
+var proxyPassHeaders = []string{
+	"Content-Type",
+	"Accept",
+	"Cache-Control",
+	"Last-Event-ID",
+}
+
 // AnyRouterProxy 反向代理
-// @Summary 代理转发请求到 AnyRouter
+// @Summary 使用已保存账号代理转发请求到 AnyRouter
+// @Description 账号 ID 通过 X-Account-ID 请求头或路径前缀 /anyrouter/{id}/... 指定，Session 由服务端读取；支持 SSE 流式响应
 // @Tags 代理
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param path path string true "目标路径"
-// @Param Authorization header string true "Bearer {session}"
+// @Param X-Account-ID header int false "账号ID"
 // @Success 200 {object} map[string]any
 // @Router /anyrouter/{path} [get]
 func AnyRouterProxy(c *gin.Context) {
-	session := extractProxySession(c.GetHeader("Authorization"))
-	if session == "" {
-		response.Unauthorized(c)
+	accountID, targetPath, ok := resolveProxyTarget(c.GetHeader(proxyAccountHeader), c.Param("path"))
+	if !ok {
+		response.Error(c, 400, "账号ID无效")
 		return
 	}
-
-	sessionInfo, err := service.ParseSession(session)
-	if err != nil {
-		zap.L().Warn("解析 session 失败", zap.Error(err), zap.String("session_prefix", session[:min(50, len(session))]))
-		response.Error(c, 400, "invalid session: "+err.Error())
+	c.Set("audit_target_id", strconv.FormatUint(uint64(accountID), 10))
+	if !authorizeAccounts(c, accountID) {
 		return
 	}
 
-	acwScV2, err := service.FetchAcwScV2()
+	account, err := service.GetProxyAccount(c.Request.Context(), accountID)
 	if err != nil {
-		zap.L().Warn("获取 acw_sc__v2 失败", zap.Error(err))
-	}
-
-	targetPath := c.Param("path")
-	if targetPath == "" {
-		targetPath = "/"
-	}
-	targetURL := anyRouterBaseURL + targetPath
-	if c.Request.URL.RawQuery != "" {
-		targetURL += "?" + c.Request.URL.RawQuery
+		var challengeErr *service.ChallengeError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.Error(c, 404, "账号不存在")
+		case errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrInvalidSession), errors.Is(err, service.ErrInvalidProxyURL):
+			response.Error(c, 400, err.Error())
+		case errors.As(err, &challengeErr):
+			response.Error(c, 502, err.Error())
+		default:
+			zap.L().Error("准备代理请求失败", zap.Uint("account_id", accountID), zap.Error(err))
+			response.Error(c, 502, "proxy request failed")
+		}
+		return
 	}
 
-	proxyReq, err := http.NewRequest(c.Request.Method, targetURL, c.Request.Body)
+	target, err := url.Parse(account.BaseURL)
 	if err != nil {
-		zap.L().Error("创建代理请求失败", zap.Error(err))
 		response.Error(c, 500, "failed to create request")
 		return
 	}
 
-	setProxyHeaders(proxyReq, session, acwScV2, sessionInfo.UserID)
-
-	client := &http.Client{Timeout: proxyTimeout}
-	resp, err := client.Do(proxyReq)
-	if err != nil {
-		zap.L().Error("代理请求失败", zap.String("url", targetURL), zap.Error(err))
-		response.Error(c, 502, "proxy request failed")
-		return
+	if limit := config.Get().Proxy.MaxBodySize; limit > 0 && c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
 	}
-	defer resp.Body.Close()
 
-	copyResponseHeaders(c, resp)
-	c.Status(resp.StatusCode)
-	io.Copy(c.Writer, resp.Body)
+	proxy := &httputil.ReverseProxy{
+		Transport:     account.Transport,
+		FlushInterval: proxyFlushInterval,
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.URL.Path = targetPath
+			r.Out.URL.RawPath = ""
+			r.Out.Host = target.Host
+
+			inbound := r.Out.Header
+			r.Out.Header = make(http.Header)
+			for _, key := range proxyPassHeaders {
+				for _, v := range inbound.Values(key) {
+					r.Out.Header.Add(key, v)
+				}
+			}
+			if config.Get().Proxy.ForwardClientIP {
+				r.Out.Header.Set("X-Forwarded-For", c.ClientIP())
+				r.Out.Header.Set("X-Forwarded-Host", r.In.Host)
+				r.Out.Header.Set("X-Forwarded-Proto", forwardedProto(r.In))
+			}
+			setProxyHeaders(r.Out, account)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// 上游下发的 Cookie 与跨域头不应暴露给调用方。
+			resp.Header.Del("Set-Cookie")
+			for key := range resp.Header {
+				if strings.HasPrefix(strings.ToLower(key), "access-control-") {
+					resp.Header.Del(key)
+				}
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				response.Error(c, 413, "请求体过大")
+				return
+			}
+			var challengeErr *service.ChallengeError
+			if errors.As(err, &challengeErr) {
+				zap.L().Warn("代理请求刷新 WAF Cookie 后仍命中挑战页", zap.Uint("account_id", account.AccountID), zap.String("path", targetPath))
+				response.Error(c, 502, err.Error())
+				return
+			}
+			zap.L().Error("代理请求失败", zap.Uint("account_id", account.AccountID), zap.String("path", targetPath), zap.Error(err))
+			response.Error(c, 502, "proxy request failed")
+		},
+	}
+	proxy.ServeHTTP(c.Writer, c.Request)
 }
 
-func extractProxySession(auth string) string {
-	if auth == "" {
-		return ""
+func forwardedProto(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
 	}
-	return strings.TrimPrefix(auth, "Bearer ")
+	return "http"
 }
 
-func setProxyHeaders(req *http.Request, session, acwScV2 string, userID int) {
-	req.Header.Set("accept", "application/json, text/plain, */*")
-	req.Header.Set("accept-language", "zh-CN,zh;q=0.9,en-US;q=0.8,en;q=0.7")
-	req.Header.Set("cache-control", "no-store")
-	req.Header.Set("pragma", "no-cache")
-	req.Header.Set("user-agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36")
-	req.Header.Set("sec-ch-ua", `"Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144"`)
-	req.Header.Set("sec-ch-ua-mobile", "?0")
-	req.Header.Set("sec-ch-ua-platform", `"macOS"`)
-	req.Header.Set("sec-fetch-dest", "empty")
-	req.Header.Set("sec-fetch-mode", "cors")
-	req.Header.Set("sec-fetch-site", "same-origin")
-	req.Header.Set("referer", anyRouterBaseURL+"/console/personal")
-
-	if userID > 0 {
-		req.Header.Set("new-api-user", strconv.Itoa(userID))
+// resolveProxyTarget 优先读取请求头中的账号 ID，否则从路径首段解析，并返回去掉前缀后的目标路径。
+func resolveProxyTarget(header, path string) (uint, string, bool) {
+	if path == "" {
+		path = "/"
+	}
+	if header = strings.TrimSpace(header); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil || id == 0 {
+			return 0, "", false
+		}
+		return uint(id), path, true
 	}
 
-	cookie := "session=" + session
-	if acwScV2 != "" {
-		cookie += "; acw_sc__v2=" + acwScV2
+	trimmed := strings.TrimPrefix(path, "/")
+	segment, rest, _ := strings.Cut(trimmed, "/")
+	id, err := strconv.ParseUint(segment, 10, 64)
+	if err != nil || id == 0 {
+		return 0, "", false
 	}
-	req.Header.Set("cookie", cookie)
+	return uint(id), "/" + rest, true
 }
 
-func copyResponseHeaders(c *gin.Context, resp *http.Response) {
-	for key, values := range resp.Header {
-		lower := strings.ToLower(key)
-		if lower == "transfer-encoding" || lower == "connection" || lower == "content-length" {
-			continue
-		}
-		for _, v := range values {
-			c.Header(key, v)
-		}
+func setProxyHeaders(req *http.Request, account service.ProxyAccount) {
+	if req.Header.Get("accept") == "" {
+		req.Header.Set("accept", "application/json, text/plain, */*")
+	}
+	if req.Header.Get("cache-control") == "" {
+		req.Header.Set("cache-control", "no-store")
 	}
+	req.Header.Set("pragma", "no-cache")
+	req.Header.Set("sec-fetch-dest", "empty")
+	req.Header.Set("sec-fetch-mode", "cors")
+	req.Header.Set("sec-fetch-site", "same-origin")
+	req.Header.Set("referer", account.BaseURL+"/console/personal")
+	account.Credential.Apply(req.Header, account.WafCookie)
 }
@@ -0,0 +1,494 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// relayMaxAttempts 为一次请求最多尝试的通道数。
+const relayMaxAttempts = 2
+
+// relayPassHeaders 为允许透传到上游的请求头，调用方的鉴权头会被替换为通道令牌。
+var relayPassHeaders = []string{
+	"Content-Type",
+	"Accept",
+	"User-Agent",
+	"Anthropic-Version",
+	"Anthropic-Beta",
+	"OpenAI-Beta",
+	"X-App",
+}
+
+// relayPassHeaderPrefixes 为按前缀透传的请求头（Anthropic / OpenAI SDK 的客户端信息）。
+var relayPassHeaderPrefixes = []string{
+	"X-Stainless-",
+}
+
+type RelayKeyRequest struct {
+	Name   string `json:"name" binding:"required" example:"claude-code"`
+	Status *int   `json:"status" example:"1"`
+}
+
+type RelayChannelRequest struct {
+	AccountID uint   `json:"account_id" example:"1"`
+	Name      string `json:"name" example:"主账号"`
+	Key       string `json:"key" example:"sk-xxxx"`
+	Weight    int    `json:"weight" example:"1"`
+	Status    *int   `json:"status" example:"1"`
+}
+
+// Relay 模型请求中转
+// @Summary 使用本系统签发的 API Key 中转 OpenAI / Anthropic 兼容请求
+// @Description 通过 Authorization: Bearer 或 x-api-key 携带 Key，请求按策略转发到中转池中的某个账号令牌，失败时换通道重试一次
+// @Tags 中转
+// @Accept json
+// @Produce json
+// @Param path path string true "目标路径，如 chat/completions、messages"
+// @Success 200 {object} map[string]any
+// @Router /v1/{path} [post]
+func Relay(c *gin.Context) {
+	rawKey, useAPIKeyHeader := relayKeyFromRequest(c.Request)
+	key, err := service.AuthenticateRelayKey(rawKey)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRelayKey) {
+			relayError(c, http.StatusUnauthorized, "authentication_error", err.Error())
+			return
+		}
+		zap.L().Error("校验 API Key 失败", zap.Error(err))
+		relayError(c, http.StatusInternalServerError, "server_error", "校验 API Key 失败")
+		return
+	}
+
+	var body []byte
+	if c.Request.Body != nil {
+		reader := io.Reader(c.Request.Body)
+		if limit := config.Get().Proxy.MaxBodySize; limit > 0 {
+			reader = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		if body, err = io.ReadAll(reader); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				relayError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", "请求体过大")
+				return
+			}
+			relayError(c, http.StatusBadRequest, "invalid_request_error", "读取请求体失败")
+			return
+		}
+	}
+	modelName, stream := service.InspectRelayRequest(body)
+
+	started := carbon.Now()
+	relayLog := model.RelayLog{
+		RelayKeyID: key.ID,
+		Model:      modelName,
+		Path:       c.Param("path"),
+		IsStream:   stream,
+	}
+	defer func() {
+		relayLog.LatencyMs = started.DiffAbsInDuration(carbon.Now()).Milliseconds()
+		service.RecordRelayLog(relayLog)
+	}()
+
+	exclude := make(map[uint]bool)
+	for attempt := 1; attempt <= relayMaxAttempts; attempt++ {
+		target, err := service.PickRelayTarget(exclude)
+		if err != nil {
+			if relayLog.StatusCode == 0 {
+				relayLog.StatusCode = http.StatusServiceUnavailable
+			}
+			if errors.Is(err, service.ErrNoRelayChannel) {
+				relayError(c, http.StatusServiceUnavailable, "server_error", err.Error())
+				return
+			}
+			zap.L().Error("选择中转通道失败", zap.Error(err))
+			relayError(c, http.StatusBadGateway, "server_error", "relay request failed")
+			return
+		}
+		relayLog.Attempts = attempt
+		relayLog.ChannelID = target.ChannelID
+		relayLog.AccountID = target.AccountID
+
+		resp, err := sendRelayRequest(c, target, body, useAPIKeyHeader)
+		if err != nil {
+			if c.Request.Context().Err() != nil {
+				relayLog.StatusCode = 499
+				return
+			}
+			service.ReportRelayResult(target, 0, err)
+			zap.L().Warn("中转请求失败", zap.Uint("channel_id", target.ChannelID), zap.Error(err))
+			relayLog.StatusCode = http.StatusBadGateway
+			exclude[target.ChannelID] = true
+			continue
+		}
+		service.ReportRelayResult(target, resp.StatusCode, nil)
+		if service.IsRelayRetryable(resp.StatusCode) && attempt < relayMaxAttempts {
+			_ = resp.Body.Close()
+			relayLog.StatusCode = resp.StatusCode
+			exclude[target.ChannelID] = true
+			continue
+		}
+
+		relayLog.StatusCode = resp.StatusCode
+		relayLog.PromptTokens, relayLog.CompletionTokens = copyRelayResponse(c, resp)
+		return
+	}
+	relayError(c, http.StatusBadGateway, "server_error", "relay request failed")
+}
+
+func sendRelayRequest(c *gin.Context, target service.RelayTarget, body []byte, useAPIKeyHeader bool) (*http.Response, error) {
+	targetURL := target.BaseURL + "/v1" + c.Param("path")
+	if c.Request.URL.RawQuery != "" {
+		targetURL += "?" + c.Request.URL.RawQuery
+	}
+	req, err := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.Request.Header {
+		if !isRelayPassHeader(key) {
+			continue
+		}
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if useAPIKeyHeader {
+		req.Header.Set("x-api-key", target.Key)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+target.Key)
+	}
+	return target.Transport.RoundTrip(req)
+}
+
+// copyRelayResponse 将上游响应写回调用方，边转发边解析 usage，返回输入与输出 token 数。
+func copyRelayResponse(c *gin.Context, resp *http.Response) (int64, int64) {
+	defer resp.Body.Close()
+
+	header := c.Writer.Header()
+	for key, values := range resp.Header {
+		switch http.CanonicalHeaderKey(key) {
+		case "Set-Cookie", "Content-Length", "Connection", "Transfer-Encoding":
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(key), "access-control-") {
+			continue
+		}
+		for _, v := range values {
+			header.Add(key, v)
+		}
+	}
+	c.Status(resp.StatusCode)
+
+	collector := service.NewRelayUsageCollector(strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			_, _ = collector.Write(buf[:n])
+			if _, writeErr := c.Writer.Write(buf[:n]); writeErr != nil {
+				break
+			}
+			c.Writer.Flush()
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				zap.L().Warn("读取中转响应失败", zap.Error(err))
+			}
+			break
+		}
+	}
+	return collector.Result()
+}
+
+// relayKeyFromRequest 读取调用方 Key，第二个返回值表示调用方使用 x-api-key（Anthropic 风格）。
+func relayKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get("x-api-key"); key != "" {
+		return key, true
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), false
+}
+
+func isRelayPassHeader(key string) bool {
+	canonical := http.CanonicalHeaderKey(key)
+	for _, pass := range relayPassHeaders {
+		if canonical == http.CanonicalHeaderKey(pass) {
+			return true
+		}
+	}
+	for _, prefix := range relayPassHeaderPrefixes {
+		if strings.HasPrefix(canonical, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// relayError 按 OpenAI / Anthropic 兼容的错误结构返回，便于 SDK 识别。
+func relayError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
+
+// ListRelayKeys API Key 列表
+// @Summary 获取中转 API Key 列表
+// @Tags 中转
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.RelayKey}
+// @Router /relay/keys [get]
+func ListRelayKeys(c *gin.Context) {
+	keys, err := service.ListRelayKeys()
+	if err != nil {
+		response.Error(c, 500, "获取 API Key 失败")
+		return
+	}
+	response.Success(c, keys)
+}
+
+// CreateRelayKey 创建 API Key
+// @Summary 签发中转 API Key
+// @Description 完整 Key 仅在创建时返回一次
+// @Tags 中转
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RelayKeyRequest true "Key 参数"
+// @Success 200 {object} response.Response{data=service.RelayKeyCreated}
+// @Router /relay/keys [post]
+func CreateRelayKey(c *gin.Context) {
+	var req RelayKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	key, err := service.CreateRelayKey(req.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRelayKey) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "创建失败")
+		return
+	}
+	response.Success(c, key)
+}
+
+// UpdateRelayKey 更新 API Key
+// @Summary 更新中转 API Key 名称与状态
+// @Tags 中转
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Key ID"
+// @Param request body RelayKeyRequest true "Key 参数"
+// @Success 200 {object} response.Response{data=model.RelayKey}
+// @Router /relay/keys/{id} [put]
+func UpdateRelayKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "Key ID无效")
+		return
+	}
+	var req RelayKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	if status != 0 && status != 1 {
+		response.Error(c, 400, "状态无效")
+		return
+	}
+	key, err := service.UpdateRelayKey(uint(id), req.Name, status)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "Key 不存在")
+			return
+		}
+		response.Error(c, 500, "更新失败")
+		return
+	}
+	response.Success(c, key)
+}
+
+// DeleteRelayKey 删除 API Key
+// @Summary 删除中转 API Key
+// @Tags 中转
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Key ID"
+// @Success 200 {object} response.Response
+// @Router /relay/keys/{id} [delete]
+func DeleteRelayKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "Key ID无效")
+		return
+	}
+	if err := service.DeleteRelayKey(uint(id)); err != nil {
+		response.Error(c, 500, "删除失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ListRelayChannels 中转通道列表
+// @Summary 获取中转池中的通道
+// @Tags 中转
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]service.RelayChannelInfo}
+// @Router /relay/channels [get]
+func ListRelayChannels(c *gin.Context) {
+	channels, err := service.ListRelayChannels()
+	if err != nil {
+		response.Error(c, 500, "获取中转通道失败")
+		return
+	}
+	response.Success(c, channels)
+}
+
+// CreateRelayChannel 添加中转通道
+// @Summary 将账号令牌加入中转池
+// @Description key 为空时在上游为该账号新建一个不限额令牌
+// @Tags 中转
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RelayChannelRequest true "通道参数"
+// @Success 200 {object} response.Response{data=model.RelayChannel}
+// @Router /relay/channels [post]
+func CreateRelayChannel(c *gin.Context) {
+	var req RelayChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.AccountID == 0 {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	channel, err := service.CreateRelayChannel(service.RelayChannelParams{
+		AccountID: req.AccountID,
+		Name:      req.Name,
+		Key:       req.Key,
+		Weight:    req.Weight,
+	})
+	if err != nil {
+		respondRelayChannelError(c, err, "创建失败")
+		return
+	}
+	response.Success(c, channel)
+}
+
+// UpdateRelayChannel 更新中转通道
+// @Summary 更新中转通道名称、权重与状态
+// @Description 重新启用会清除失败与冷却记录
+// @Tags 中转
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通道ID"
+// @Param request body RelayChannelRequest true "通道参数"
+// @Success 200 {object} response.Response{data=model.RelayChannel}
+// @Router /relay/channels/{id} [put]
+func UpdateRelayChannel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "通道ID无效")
+		return
+	}
+	var req RelayChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	channel, err := service.UpdateRelayChannel(uint(id), req.Name, req.Weight, status)
+	if err != nil {
+		respondRelayChannelError(c, err, "更新失败")
+		return
+	}
+	response.Success(c, channel)
+}
+
+// DeleteRelayChannel 删除中转通道
+// @Summary 将令牌移出中转池（不删除上游令牌）
+// @Tags 中转
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通道ID"
+// @Success 200 {object} response.Response
+// @Router /relay/channels/{id} [delete]
+func DeleteRelayChannel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "通道ID无效")
+		return
+	}
+	if err := service.DeleteRelayChannel(uint(id)); err != nil {
+		response.Error(c, 500, "删除失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RelayStats 中转用量
+// @Summary 按日期与调用方 Key、账号或模型聚合中转用量
+// @Description 未指定日期时默认统计最近 30 天
+// @Tags 中转
+// @Produce json
+// @Security BearerAuth
+// @Param group_by query string false "聚合维度" Enums(key, account, model) default(key)
+// @Param start_date query string false "开始日期 YYYY-MM-DD"
+// @Param end_date query string false "结束日期 YYYY-MM-DD"
+// @Success 200 {object} response.Response{data=[]service.RelayStatItem}
+// @Router /relay/stats [get]
+func RelayStats(c *gin.Context) {
+	stats, err := service.RelayStats(c.Query("start_date"), c.Query("end_date"), c.Query("group_by"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUsageQuery) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "获取中转用量失败")
+		return
+	}
+	response.Success(c, stats)
+}
+
+func respondRelayChannelError(c *gin.Context, err error, fallback string) {
+	var apiErr *service.UpstreamAPIError
+	var challengeErr *service.ChallengeError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.Error(c, 404, "账号或通道不存在")
+	case errors.Is(err, service.ErrInvalidRelayChannel), errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrInvalidSession):
+		response.Error(c, 400, err.Error())
+	case errors.As(err, &apiErr), errors.As(err, &challengeErr):
+		response.Error(c, 502, err.Error())
+	default:
+		response.Error(c, 500, fallback)
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestScope 返回当前用户的可见范围，失败时写入响应并返回 false。
+func requestScope(c *gin.Context) (service.Scope, bool) {
+	scope, err := service.ResolveScope(c.GetUint("user_id"))
+	if err != nil {
+		response.Error(c, 500, "获取用户信息失败")
+		return service.Scope{}, false
+	}
+	return scope, true
+}
+
+// authorizeAccounts 校验账号对当前用户可见，不可见的账号与不存在一样返回 404，失败时写入响应并返回 false。
+func authorizeAccounts(c *gin.Context, accountIDs ...uint) bool {
+	scope, ok := requestScope(c)
+	if !ok {
+		return false
+	}
+	if err := service.CheckAccountAccess(scope, accountIDs...); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, 404, "账号不存在")
+			return false
+		}
+		response.Error(c, 500, "获取账号失败")
+		return false
+	}
+	return true
+}
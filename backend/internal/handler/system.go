@@ -14,13 +14,17 @@ import (
 // @Tags 系统配置
 // @Produce json
 // @Security BearerAuth
-// @Param category path string true "配置分类" Enums(telegram, system)
+// @Param category path string true "配置分类" Enums(telegram, waf, relay, scheduler)
 // @Success 200 {object} response.Response{data=map[string]string}
 // @Router /config/{category} [get]
 func GetConfigs(c *gin.Context) {
 	category := c.Param("category")
 	result, err := service.GetConfigs(category)
 	if err != nil {
+		if errors.Is(err, service.ErrConfigCategoryNotFound) {
+			response.Error(c, 404, err.Error())
+			return
+		}
 		response.Error(c, 500, "获取配置失败")
 		return
 	}
@@ -29,11 +33,12 @@ func GetConfigs(c *gin.Context) {
 
 // UpdateConfigs 更新配置
 // @Summary 更新指定分类的配置
+// @Description 敏感配置提交掩码 ****** 时保持原值；校验失败时返回 code 400，data.errors 为各字段的错误信息
 // @Tags 系统配置
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param category path string true "配置分类" Enums(telegram, system)
+// @Param category path string true "配置分类" Enums(telegram, waf, relay, scheduler)
 // @Param request body map[string]string true "配置键值对"
 // @Success 200 {object} response.Response
 // @Router /config/{category} [put]
@@ -45,11 +50,17 @@ func UpdateConfigs(c *gin.Context) {
 		return
 	}
 
-	for key, value := range req {
-		if err := service.SetConfig(key, value, category); err != nil {
+	if err := service.UpdateConfigs(category, req, configActor(c)); err != nil {
+		var validationErr *service.ConfigValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			response.ErrorWithData(c, 400, err.Error(), gin.H{"errors": validationErr.Fields})
+		case errors.Is(err, service.ErrConfigCategoryNotFound):
+			response.Error(c, 404, err.Error())
+		default:
 			response.Error(c, 500, "保存配置失败")
-			return
 		}
+		return
 	}
 
 	response.Success(c, nil)
@@ -83,7 +94,11 @@ func TestTelegram(c *gin.Context) {
 // @Success 200 {object} response.Response{data=service.CheckinLogSummary}
 // @Router /logs [get]
 func ListLogs(c *gin.Context) {
-	data, err := service.GetCheckinLogSummary(100)
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	data, err := service.GetCheckinLogSummary(scope, 100)
 	if err != nil {
 		response.Error(c, 500, "获取签到日志失败")
 		return
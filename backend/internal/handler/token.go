@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type CreateAccountTokenRequest struct {
+	Name        string          `json:"name" binding:"required" example:"default"`
+	QuotaLimit  decimal.Decimal `json:"quota_limit" swaggertype:"number" example:"10"`
+	ExpiredTime int64           `json:"expired_time" example:"0"`
+	Group       string          `json:"group" example:"default"`
+}
+
+// ListAccountTokens 令牌列表
+// @Summary 获取账号在上游的 API 令牌
+// @Description Key 已脱敏，额度以美元计
+// @Tags 令牌管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=service.AccountTokenPage}
+// @Router /accounts/{id}/tokens [get]
+func ListAccountTokens(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "账号ID无效")
+		return
+	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	tokens, err := service.ListAccountTokens(uint(id), page, size)
+	if err != nil {
+		respondTokenError(c, err, "获取令牌失败")
+		return
+	}
+	response.Success(c, tokens)
+}
+
+// CreateAccountToken 创建令牌
+// @Summary 在上游创建 API 令牌
+// @Description quota_limit 以美元计，为 0 表示不限额；expired_time 为 Unix 秒，为 0 表示永不过期。返回的完整 Key 仅展示一次
+// @Tags 令牌管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Param request body CreateAccountTokenRequest true "令牌参数"
+// @Success 200 {object} response.Response{data=service.AccountToken}
+// @Router /accounts/{id}/tokens [post]
+func CreateAccountToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "账号ID无效")
+		return
+	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
+
+	var req CreateAccountTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+
+	token, err := service.CreateAccountToken(uint(id), service.CreateTokenParams{
+		Name:        req.Name,
+		QuotaLimit:  req.QuotaLimit,
+		ExpiredTime: req.ExpiredTime,
+		Group:       req.Group,
+	})
+	if err != nil {
+		respondTokenError(c, err, "创建令牌失败")
+		return
+	}
+	response.Success(c, token)
+}
+
+// DeleteAccountToken 删除令牌
+// @Summary 撤销上游 API 令牌
+// @Tags 令牌管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Param token_id path int true "令牌ID"
+// @Success 200 {object} response.Response
+// @Router /accounts/{id}/tokens/{token_id} [delete]
+func DeleteAccountToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "账号ID无效")
+		return
+	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
+	tokenID, err := strconv.Atoi(c.Param("token_id"))
+	if err != nil {
+		response.Error(c, 400, "令牌ID无效")
+		return
+	}
+
+	if err := service.DeleteAccountToken(uint(id), tokenID); err != nil {
+		respondTokenError(c, err, "删除令牌失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+func respondTokenError(c *gin.Context, err error, fallback string) {
+	var apiErr *service.UpstreamAPIError
+	var challengeErr *service.ChallengeError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.Error(c, 404, "账号不存在")
+	case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrInvalidSession), errors.Is(err, service.ErrInvalidProxyURL):
+		response.Error(c, 400, err.Error())
+	case errors.As(err, &apiErr), errors.As(err, &challengeErr):
+		response.Error(c, 502, err.Error())
+	default:
+		response.Error(c, 500, fallback)
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TopupRequest struct {
+	Codes      []string `json:"codes" binding:"required" example:"CODE-1,CODE-2"`
+	AccountIDs []uint   `json:"account_ids" binding:"required" example:"1,2"`
+}
+
+// RedeemCodes 兑换码充值
+// @Summary 使用兑换码为账号充值
+// @Description 兑换码按顺序轮流分配给目标账号，完成后刷新余额并记录余额变化
+// @Tags 充值
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TopupRequest true "兑换码与目标账号"
+// @Success 200 {object} response.Response{data=service.TopupResult}
+// @Router /topup [post]
+func RedeemCodes(c *gin.Context) {
+	var req TopupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+
+	if !authorizeAccounts(c, req.AccountIDs...) {
+		return
+	}
+	result, err := service.RedeemCodes(req.Codes, req.AccountIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.Error(c, 404, "账号不存在")
+		case errors.Is(err, service.ErrInvalidTopup), errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrInvalidSession):
+			response.Error(c, 400, err.Error())
+		default:
+			response.Error(c, 500, "充值失败")
+		}
+		return
+	}
+	response.Success(c, result)
+}
+
+// ListTopupRecords 充值记录
+// @Summary 获取兑换码充值记录
+// @Tags 充值
+// @Produce json
+// @Security BearerAuth
+// @Param account_id query int false "账号ID"
+// @Success 200 {object} response.Response{data=[]model.TopupRecord}
+// @Router /topup [get]
+func ListTopupRecords(c *gin.Context) {
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	accountID, _ := strconv.ParseUint(c.Query("account_id"), 10, 64)
+	records, err := service.ListTopupRecords(scope, uint(accountID), 200)
+	if err != nil {
+		response.Error(c, 500, "获取充值记录失败")
+		return
+	}
+	response.Success(c, records)
+}
+
+// ListBalanceHistory 余额变化
+// @Summary 获取账号余额变化记录
+// @Tags 充值
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Success 200 {object} response.Response{data=[]model.BalanceHistory}
+// @Router /accounts/{id}/balance-history [get]
+func ListBalanceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "账号ID无效")
+		return
+	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
+	histories, err := service.ListBalanceHistory(uint(id), 200)
+	if err != nil {
+		response.Error(c, 500, "获取余额记录失败")
+		return
+	}
+	response.Success(c, histories)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"challenge-token"`
+	Code           string `json:"code" binding:"required" example:"123456"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required" example:"admin123"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// TwoFactorLogin 两步验证登录
+// @Summary 提交动态码或恢复码完成登录
+// @Description 每个 challenge_token 有效期 5 分钟，最多尝试 5 次，需与登录时的 IP 一致；动态码错误计入登录失败次数
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "验证参数"
+// @Success 200 {object} response.Response{data=service.LoginResult}
+// @Router /auth/login/2fa [post]
+func TwoFactorLogin(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	result, err := service.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, loginClient(c))
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// SetupTwoFactor 开始绑定两步验证
+// @Summary 生成 TOTP 密钥
+// @Description 使用验证器应用扫描 otpauth_uri 后，调用 /auth/2fa/enable 提交首个动态码完成绑定
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=service.TwoFactorSetup}
+// @Router /auth/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	setup, err := service.SetupTwoFactor(c.GetUint("user_id"))
+	if err != nil {
+		respondTwoFactorError(c, err, "生成密钥失败")
+		return
+	}
+	response.Success(c, setup)
+}
+
+// EnableTwoFactor 开启两步验证
+// @Summary 验证首个动态码并开启两步验证
+// @Description 返回的恢复码仅显示一次，每个恢复码可代替动态码使用一次
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "动态码"
+// @Success 200 {object} response.Response{data=map[string][]string}
+// @Router /auth/2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	codes, err := service.EnableTwoFactor(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "开启失败")
+		return
+	}
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
+
+// DisableTwoFactor 关闭两步验证
+// @Summary 关闭两步验证
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DisableTwoFactorRequest true "密码与动态码"
+// @Success 200 {object} response.Response
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	if err := service.DisableTwoFactor(c.GetUint("user_id"), req.Password, req.Code); err != nil {
+		respondTwoFactorError(c, err, "关闭失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 旧恢复码全部失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "动态码"
+// @Success 200 {object} response.Response{data=map[string][]string}
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	codes, err := service.RegenerateRecoveryCodes(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "生成失败")
+		return
+	}
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
+
+// ResetUserTwoFactor 重置用户两步验证
+// @Summary 管理员清除用户的两步验证（用户丢失设备与恢复码时使用）
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} response.Response
+// @Router /users/{id}/2fa [delete]
+func ResetUserTwoFactor(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "用户ID无效")
+		return
+	}
+	if err := service.ResetTwoFactor(uint(id)); err != nil {
+		respondUserError(c, err, "重置失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+func respondTwoFactorError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.Error(c, 404, "用户不存在")
+	case errors.Is(err, service.ErrTwoFactorCode), errors.Is(err, service.ErrTwoFactorEnabled), errors.Is(err, service.ErrTwoFactorDisabled):
+		response.Error(c, 400, err.Error())
+	default:
+		response.Error(c, 500, fallback)
+	}
+}
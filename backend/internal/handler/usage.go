@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListUsageRecords 消费记录
+// @Summary 查询已同步的上游消费记录
+// @Tags 消费统计
+// @Produce json
+// @Security BearerAuth
+// @Param account_id query int false "账号ID"
+// @Param model query string false "模型名称"
+// @Param start_date query string false "开始日期 YYYY-MM-DD"
+// @Param end_date query string false "结束日期 YYYY-MM-DD"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50)
+// @Success 200 {object} response.Response{data=service.UsageRecordPage}
+// @Router /usage [get]
+func ListUsageRecords(c *gin.Context) {
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	records, err := service.ListUsageRecords(usageFilterFromQuery(c, scope), page, size)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUsageQuery) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "获取消费记录失败")
+		return
+	}
+	response.Success(c, records)
+}
+
+// UsageStats 消费统计
+// @Summary 按日期与账号、模型或令牌聚合消费
+// @Description 未指定日期时默认统计最近 30 天，cost 以美元计
+// @Tags 消费统计
+// @Produce json
+// @Security BearerAuth
+// @Param group_by query string false "聚合维度" Enums(account, model, token) default(account)
+// @Param account_id query int false "账号ID"
+// @Param model query string false "模型名称"
+// @Param start_date query string false "开始日期 YYYY-MM-DD"
+// @Param end_date query string false "结束日期 YYYY-MM-DD"
+// @Success 200 {object} response.Response{data=[]service.UsageStatItem}
+// @Router /usage/stats [get]
+func UsageStats(c *gin.Context) {
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	stats, err := service.UsageStats(usageFilterFromQuery(c, scope), c.Query("group_by"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUsageQuery) {
+			response.Error(c, 400, err.Error())
+			return
+		}
+		response.Error(c, 500, "获取消费统计失败")
+		return
+	}
+	response.Success(c, stats)
+}
+
+// ListUsageCursors 同步状态
+// @Summary 获取各账号消费日志的同步状态
+// @Tags 消费统计
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.UsageCursor}
+// @Router /usage/cursors [get]
+func ListUsageCursors(c *gin.Context) {
+	scope, ok := requestScope(c)
+	if !ok {
+		return
+	}
+	cursors, err := service.ListUsageCursors(scope)
+	if err != nil {
+		response.Error(c, 500, "获取同步状态失败")
+		return
+	}
+	response.Success(c, cursors)
+}
+
+// SyncAccountUsage 同步消费日志
+// @Summary 立即同步账号的上游消费日志
+// @Tags 消费统计
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Success 200 {object} response.Response{data=service.UsageSyncResult}
+// @Router /accounts/{id}/usage/sync [post]
+func SyncAccountUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "账号ID无效")
+		return
+	}
+	if !authorizeAccounts(c, uint(id)) {
+		return
+	}
+
+	result, err := service.SyncAccountUsage(uint(id))
+	if err != nil {
+		var apiErr *service.UpstreamAPIError
+		var challengeErr *service.ChallengeError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.Error(c, 404, "账号不存在")
+		case errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrInvalidSession), errors.Is(err, service.ErrInvalidProxyURL):
+			response.Error(c, 400, err.Error())
+		case errors.As(err, &apiErr), errors.As(err, &challengeErr):
+			response.Error(c, 502, err.Error())
+		default:
+			response.Error(c, 500, "同步失败")
+		}
+		return
+	}
+	response.Success(c, result)
+}
+
+func usageFilterFromQuery(c *gin.Context, scope service.Scope) service.UsageFilter {
+	accountID, _ := strconv.ParseUint(c.Query("account_id"), 10, 64)
+	return service.UsageFilter{
+		AccountID: uint(accountID),
+		ModelName: c.Query("model"),
+		StartDate: c.Query("start_date"),
+		EndDate:   c.Query("end_date"),
+		Scope:     scope,
+	}
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required" example:"alice"`
+	Password string `json:"password" binding:"required" example:"password123"`
+	Role     string `json:"role" binding:"required" example:"operator"`
+}
+
+type UpdateUserRequest struct {
+	Role   string `json:"role" binding:"required" example:"viewer"`
+	Status *int   `json:"status" binding:"required" example:"1"`
+	TeamID *uint  `json:"team_id" example:"1"`
+}
+
+type TeamRequest struct {
+	Name string `json:"name" binding:"required" example:"运维组"`
+}
+
+type NotificationRequest struct {
+	TelegramChatID string `json:"telegram_chat_id" example:"123456789"`
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password" binding:"required" example:"newpass123"`
+}
+
+// ListUsers 用户列表
+// @Summary 获取后台用户列表
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.User}
+// @Router /users [get]
+func ListUsers(c *gin.Context) {
+	users, err := service.ListUsers()
+	if err != nil {
+		response.Error(c, 500, "获取用户失败")
+		return
+	}
+	response.Success(c, users)
+}
+
+// CreateUser 创建用户
+// @Summary 创建后台用户
+// @Description role 可选 admin、operator、viewer
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateUserRequest true "用户参数"
+// @Success 200 {object} response.Response{data=model.User}
+// @Router /users [post]
+func CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	user, err := service.CreateUser(req.Username, req.Password, req.Role)
+	if err != nil {
+		respondUserError(c, err, "创建失败")
+		return
+	}
+	response.Success(c, user)
+}
+
+// UpdateUser 更新用户
+// @Summary 修改用户角色、状态与所属团队
+// @Description status 为 0 时禁用用户，已签发的登录令牌随即失效；team_id 为空时移出团队
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body UpdateUserRequest true "用户参数"
+// @Success 200 {object} response.Response{data=model.User}
+// @Router /users/{id} [put]
+func UpdateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "用户ID无效")
+		return
+	}
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	user, err := service.UpdateUser(c.GetUint("user_id"), uint(id), req.Role, *req.Status, req.TeamID)
+	if err != nil {
+		respondUserError(c, err, "更新失败")
+		return
+	}
+	response.Success(c, user)
+}
+
+// ResetUserPassword 重置密码
+// @Summary 管理员重置用户密码
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body ResetPasswordRequest true "新密码"
+// @Success 200 {object} response.Response
+// @Router /users/{id}/password [put]
+func ResetUserPassword(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "用户ID无效")
+		return
+	}
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	if err := service.ResetUserPassword(uint(id), req.Password); err != nil {
+		respondUserError(c, err, "重置失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+// UpdateNotification 设置通知会话
+// @Summary 设置当前用户接收签到通知的 Telegram 会话
+// @Description 为空时使用系统配置的 telegram.chat_id
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body NotificationRequest true "通知参数"
+// @Success 200 {object} response.Response{data=model.User}
+// @Router /auth/notification [put]
+func UpdateNotification(c *gin.Context) {
+	var req NotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	user, err := service.SetNotificationChat(c.GetUint("user_id"), req.TelegramChatID)
+	if err != nil {
+		respondUserError(c, err, "保存失败")
+		return
+	}
+	response.Success(c, user)
+}
+
+// ListTeams 团队列表
+// @Summary 获取团队列表
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.Team}
+// @Router /teams [get]
+func ListTeams(c *gin.Context) {
+	teams, err := service.ListTeams()
+	if err != nil {
+		response.Error(c, 500, "获取团队失败")
+		return
+	}
+	response.Success(c, teams)
+}
+
+// CreateTeam 创建团队
+// @Summary 创建团队
+// @Description 同一团队的成员可互相查看与操作对方的账号和定时任务
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TeamRequest true "团队参数"
+// @Success 200 {object} response.Response{data=model.Team}
+// @Router /teams [post]
+func CreateTeam(c *gin.Context) {
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	team, err := service.CreateTeam(req.Name)
+	if err != nil {
+		respondTeamError(c, err, "创建失败")
+		return
+	}
+	response.Success(c, team)
+}
+
+// UpdateTeam 重命名团队
+// @Summary 重命名团队
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param request body TeamRequest true "团队参数"
+// @Success 200 {object} response.Response{data=model.Team}
+// @Router /teams/{id} [put]
+func UpdateTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "团队ID无效")
+		return
+	}
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "参数错误")
+		return
+	}
+	team, err := service.UpdateTeam(uint(id), req.Name)
+	if err != nil {
+		respondTeamError(c, err, "更新失败")
+		return
+	}
+	response.Success(c, team)
+}
+
+// DeleteTeam 删除团队
+// @Summary 删除团队
+// @Description 成员会被移出团队，各自的账号与任务保留
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} response.Response
+// @Router /teams/{id} [delete]
+func DeleteTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, 400, "团队ID无效")
+		return
+	}
+	if err := service.DeleteTeam(uint(id)); err != nil {
+		response.Error(c, 500, "删除失败")
+		return
+	}
+	response.Success(c, nil)
+}
+
+func respondTeamError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.Error(c, 404, "团队不存在")
+	case errors.Is(err, service.ErrInvalidTeam):
+		response.Error(c, 400, err.Error())
+	default:
+		response.Error(c, 500, fallback)
+	}
+}
+
+func respondUserError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.Error(c, 404, "用户不存在")
+	case errors.Is(err, service.ErrInvalidUser), errors.Is(err, service.ErrUserExists), errors.Is(err, service.ErrLastAdmin):
+		response.Error(c, 400, err.Error())
+	default:
+		response.Error(c, 500, fallback)
+	}
+}
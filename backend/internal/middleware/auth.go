@@ -1,18 +1,41 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"strings"
 
 	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
 	"anyrouter-checkin/pkg/response"
 
+	"github.com/dromara/carbon/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 )
 
+// PersonalTokenPrefix 为个人访问令牌的前缀，用于与 JWT 区分。
+const PersonalTokenPrefix = "arp_"
+
+// 认证方式，写入上下文的 auth_type。
+const (
+	AuthTypeSession = "session"
+	AuthTypeToken   = "token"
+)
+
+var errTokenExpired = errors.New("token expired")
+
+var errSessionRevoked = errors.New("session revoked")
+
+// Claims 为访问令牌的载荷，SessionID 与 TokenVersion 用于吊销：会话删除或用户令牌版本变更后立即失效。
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID       uint   `json:"user_id"`
+	Username     string `json:"username"`
+	SessionID    uint   `json:"sid"`
+	TokenVersion int    `json:"ver"`
 	jwt.RegisteredClaims
 }
 
@@ -26,22 +49,170 @@ func Auth() gin.HandlerFunc {
 		}
 
 		token := strings.TrimPrefix(auth, "Bearer ")
-		claims, err := ParseToken(token)
+		authType := AuthTypeSession
+		var user *model.User
+		var role string
+		var err error
+		if strings.HasPrefix(token, PersonalTokenPrefix) {
+			authType = AuthTypeToken
+			user, role, err = authenticatePersonalToken(c, token)
+		} else {
+			user, err = authenticateSession(c, token)
+			if err == nil {
+				role = user.Role
+			}
+		}
 		if err != nil {
 			response.Unauthorized(c)
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		c.Set("user_id", user.ID)
+		c.Set("username", user.Username)
+		c.Set("role", role)
+		c.Set("auth_type", authType)
+		c.Next()
+	}
+}
+
+// authenticateSession 校验登录签发的 JWT，并读取用户当前的角色与状态，禁用或降级可立即生效；
+// 所属会话已被吊销或用户令牌版本已变更（如修改密码）时拒绝。
+func authenticateSession(c *gin.Context, token string) (*model.User, error) {
+	claims, err := ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := loadActiveUser(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, errSessionRevoked
+	}
+	session, err := repository.GetLoginSession(claims.SessionID)
+	if err != nil || session.UserID != user.ID {
+		return nil, errSessionRevoked
+	}
+	c.Set("session_id", session.ID)
+	return user, nil
+}
+
+// authenticatePersonalToken 校验个人访问令牌，返回的角色不超过令牌权限范围与用户当前角色。
+func authenticatePersonalToken(c *gin.Context, raw string) (*model.User, string, error) {
+	token, err := repository.GetPersonalTokenByHash(HashPersonalToken(raw))
+	if err != nil {
+		return nil, "", err
+	}
+	if token.ExpiresAt != nil && token.ExpiresAt.Lt(carbon.Now()) {
+		return nil, "", errTokenExpired
+	}
+	user, err := loadActiveUser(token.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	role := TokenScopeRole(token.Scopes)
+	if role == "" {
+		return nil, "", errors.New("invalid token scopes")
+	}
+	if !HasRole(user.Role, role) {
+		role = user.Role
+	}
+	if err := repository.TouchPersonalToken(token.ID); err != nil {
+		zap.L().Warn("更新令牌使用时间失败", zap.Uint("token_id", token.ID), zap.Error(err))
+	}
+	c.Set("token_id", token.ID)
+	return user, role, nil
+}
+
+func loadActiveUser(id uint) (*model.User, error) {
+	user, err := repository.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != 1 {
+		return nil, errors.New("user disabled")
+	}
+	return user, nil
+}
+
+// HashPersonalToken 返回令牌的 SHA-256 哈希，数据库中只保存哈希。
+func HashPersonalToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequireSession 要求使用登录会话访问，个人访问令牌不能管理令牌或修改密码。
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_type") != AuthTypeSession {
+			response.Forbidden(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// roleLevels 为角色的权限等级，数值越大权限越高。
+var roleLevels = map[string]int{
+	model.RoleViewer:   1,
+	model.RoleOperator: 2,
+	model.RoleAdmin:    3,
+}
+
+// RequireRole 要求当前用户的角色不低于 role，需在 Auth 之后使用。
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasRole(c.GetString("role"), role) {
+			response.Forbidden(c)
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
+// HasRole 判断 actual 角色是否具备 required 角色的权限。
+func HasRole(actual, required string) bool {
+	level, ok := roleLevels[actual]
+	return ok && level >= roleLevels[required]
+}
+
+// tokenScopeRoles 为令牌权限范围对应的角色，范围可叠加，取其中最高的角色。
+var tokenScopeRoles = map[string]string{
+	model.TokenScopeRead:    model.RoleViewer,
+	model.TokenScopeCheckin: model.RoleOperator,
+	model.TokenScopeAdmin:   model.RoleAdmin,
+}
+
+// TokenScopeRole 返回逗号分隔的权限范围对应的最高角色。
+func TokenScopeRole(scopes string) string {
+	role := ""
+	for _, scope := range strings.Split(scopes, ",") {
+		scopeRole, ok := tokenScopeRoles[strings.TrimSpace(scope)]
+		if ok && (role == "" || roleLevels[scopeRole] > roleLevels[role]) {
+			role = scopeRole
+		}
+	}
+	return role
+}
+
+// ValidTokenScope 判断是否为受支持的令牌权限范围。
+func ValidTokenScope(scope string) bool {
+	_, ok := tokenScopeRoles[scope]
+	return ok
+}
+
+// ValidRole 判断是否为受支持的角色。
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.C.JWT.Secret), nil
+		return []byte(config.Get().JWT.Secret), nil
 	})
 	if err != nil {
 		return nil, err
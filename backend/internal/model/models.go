@@ -6,17 +6,102 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色，权限依次递减：admin 可管理用户与系统配置，operator 可执行签到等操作，viewer 只读。
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
 type User struct {
+	ID             uint            `gorm:"primarykey" json:"id"`
+	Username       string          `gorm:"uniqueIndex;size:50" json:"username"`
+	Password       string          `gorm:"size:255" json:"-"`
+	Role           string          `gorm:"size:20;index" json:"role"`
+	Status         int             `gorm:"default:1" json:"status"`
+	TeamID         *uint           `gorm:"index" json:"team_id"`
+	TelegramChatID string          `gorm:"size:100" json:"telegram_chat_id"`
+	TOTPEnabled    bool            `json:"totp_enabled"`
+	TOTPSecret     string          `gorm:"size:255" json:"-"`
+	TOTPLastStep   int64           `json:"-"`
+	RecoveryCodes  string          `gorm:"type:text" json:"-"`
+	TokenVersion   int             `gorm:"default:0" json:"-"`
+	OIDCSubject    string          `gorm:"column:oidc_subject;size:255;index" json:"oidc_subject,omitempty"`
+	CreatedAt      carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
+	UpdatedAt      carbon.DateTime `json:"updated_at" swaggertype:"string" format:"date-time"`
+	DeletedAt      gorm.DeletedAt  `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+// 个人访问令牌的权限范围，依次对应 viewer、operator、admin 角色的权限。
+const (
+	TokenScopeRead    = "read"
+	TokenScopeCheckin = "checkin"
+	TokenScopeAdmin   = "admin"
+)
+
+// PersonalToken 为用户签发给脚本使用的长期访问令牌，仅保存哈希。
+type PersonalToken struct {
+	ID          uint             `gorm:"primarykey" json:"id"`
+	UserID      uint             `gorm:"index" json:"user_id"`
+	Name        string           `gorm:"size:100" json:"name"`
+	TokenHash   string           `gorm:"uniqueIndex;size:64" json:"-"`
+	TokenPrefix string           `gorm:"size:20" json:"token_prefix"`
+	Scopes      string           `gorm:"size:100" json:"scopes"`
+	ExpiresAt   *carbon.DateTime `json:"expires_at" swaggertype:"string" format:"date-time"`
+	LastUsedAt  *carbon.DateTime `json:"last_used_at" swaggertype:"string" format:"date-time"`
+	CreatedAt   carbon.DateTime  `json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
+// LoginSession 为一次登录会话，持有可轮换的刷新令牌，删除后该会话签发的访问令牌立即失效。
+type LoginSession struct {
+	ID           uint             `gorm:"primarykey" json:"id"`
+	UserID       uint             `gorm:"index" json:"user_id"`
+	RefreshHash  string           `gorm:"uniqueIndex;size:64" json:"-"`
+	PreviousHash string           `gorm:"index;size:64" json:"-"`
+	IP           string           `gorm:"size:64" json:"ip"`
+	UserAgent    string           `gorm:"size:255" json:"user_agent"`
+	ExpiresAt    carbon.DateTime  `json:"expires_at" swaggertype:"string" format:"date-time"`
+	LastUsedAt   *carbon.DateTime `json:"last_used_at" swaggertype:"string" format:"date-time"`
+	CreatedAt    carbon.DateTime  `json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
+// AuditEvent 为一次修改类 API 调用的审计记录，Before 与 After 为变更字段的 JSON，敏感字段已脱敏。
+// Code 为响应中的 code，0 表示成功。
+type AuditEvent struct {
+	ID         uint            `gorm:"primarykey" json:"id"`
+	ActorID    uint            `gorm:"index" json:"actor_id"`
+	ActorName  string          `gorm:"size:50;index" json:"actor_name"`
+	AuthType   string          `gorm:"size:20" json:"auth_type"`
+	Action     string          `gorm:"size:150;index" json:"action"`
+	TargetType string          `gorm:"size:50;index" json:"target_type"`
+	TargetID   string          `gorm:"size:64;index" json:"target_id"`
+	Before     string          `gorm:"type:text" json:"before,omitempty"`
+	After      string          `gorm:"type:text" json:"after,omitempty"`
+	Code       int             `json:"code"`
+	IP         string          `gorm:"size:64" json:"ip"`
+	CreatedAt  carbon.DateTime `gorm:"index" json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
+// LoginAttempt 为失败的登录尝试，供管理员排查暴力破解。
+type LoginAttempt struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	Username  string          `gorm:"size:50;index" json:"username"`
+	IP        string          `gorm:"size:64;index" json:"ip"`
+	Reason    string          `gorm:"size:50" json:"reason"`
+	CreatedAt carbon.DateTime `gorm:"index" json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
+// Team 为共享账号与定时任务的用户分组，同一团队的成员互相可见。
+type Team struct {
 	ID        uint            `gorm:"primarykey" json:"id"`
-	Username  string          `gorm:"uniqueIndex;size:50" json:"username"`
-	Password  string          `gorm:"size:255" json:"-"`
+	Name      string          `gorm:"uniqueIndex;size:50" json:"name"`
 	CreatedAt carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
 	UpdatedAt carbon.DateTime `json:"updated_at" swaggertype:"string" format:"date-time"`
-	DeletedAt gorm.DeletedAt  `gorm:"index" json:"-" swaggerignore:"true"`
 }
 
 type Account struct {
 	ID          uint             `gorm:"primarykey" json:"id"`
+	OwnerID     uint             `gorm:"index" json:"owner_id"`
 	Session     string           `gorm:"type:text" json:"-"`
 	UserID      int              `json:"user_id"`
 	Username    string           `gorm:"size:100" json:"username"`
@@ -25,12 +110,27 @@ type Account struct {
 	Balance     decimal.Decimal  `gorm:"type:decimal(20,2);default:0" json:"balance"`
 	LastCheckin *carbon.DateTime `json:"last_checkin" swaggertype:"string" format:"date-time"`
 	LastResult  string           `gorm:"size:255" json:"last_result"`
+	ProxyURL    string           `gorm:"size:255" json:"proxy_url"`
+	ProfileID   *uint            `gorm:"index" json:"profile_id"`
 	CreatedAt   carbon.DateTime  `json:"created_at" swaggertype:"string" format:"date-time"`
 	UpdatedAt   carbon.DateTime  `json:"updated_at" swaggertype:"string" format:"date-time"`
 }
 
+type BrowserProfile struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	Name            string          `gorm:"uniqueIndex;size:100" json:"name"`
+	UserAgent       string          `gorm:"size:512" json:"user_agent"`
+	SecChUa         string          `gorm:"size:255" json:"sec_ch_ua"`
+	SecChUaMobile   string          `gorm:"size:10" json:"sec_ch_ua_mobile"`
+	SecChUaPlatform string          `gorm:"size:50" json:"sec_ch_ua_platform"`
+	AcceptLanguage  string          `gorm:"size:255" json:"accept_language"`
+	CreatedAt       carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
+	UpdatedAt       carbon.DateTime `json:"updated_at" swaggertype:"string" format:"date-time"`
+}
+
 type CronTask struct {
 	ID         uint             `gorm:"primarykey" json:"id"`
+	OwnerID    uint             `gorm:"index" json:"owner_id"`
 	Name       string           `gorm:"size:100" json:"name"`
 	CronExpr   string           `gorm:"size:50" json:"cron_expr"`
 	TaskType   string           `gorm:"size:50" json:"task_type"`
@@ -49,6 +149,19 @@ type Config struct {
 	UpdatedAt carbon.DateTime `json:"updated_at" swaggertype:"string" format:"date-time"`
 }
 
+// ConfigVersion 为系统配置的一次变更，Source 为 update、rollback 或 import。
+type ConfigVersion struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	Key       string          `gorm:"size:100;index" json:"key"`
+	Category  string          `gorm:"size:50;index" json:"category"`
+	OldValue  string          `gorm:"type:text" json:"old_value"`
+	NewValue  string          `gorm:"type:text" json:"new_value"`
+	Source    string          `gorm:"size:20" json:"source"`
+	ActorID   uint            `gorm:"index" json:"actor_id"`
+	ActorName string          `gorm:"size:50" json:"actor_name"`
+	CreatedAt carbon.DateTime `gorm:"index" json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
 type CheckinLog struct {
 	ID        uint            `gorm:"primarykey" json:"id"`
 	AccountID uint            `gorm:"index" json:"account_id"`
@@ -56,3 +169,99 @@ type CheckinLog struct {
 	Message   string          `gorm:"type:text" json:"message"`
 	CreatedAt carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
 }
+
+// UsageRecord 为从上游同步的消费日志，(AccountID, UpstreamID) 唯一。
+type UsageRecord struct {
+	ID               uint            `gorm:"primarykey" json:"id"`
+	AccountID        uint            `gorm:"uniqueIndex:idx_usage_account_upstream;index:idx_usage_account_date" json:"account_id"`
+	UpstreamID       int64           `gorm:"uniqueIndex:idx_usage_account_upstream" json:"upstream_id"`
+	ModelName        string          `gorm:"size:100;index" json:"model_name"`
+	TokenName        string          `gorm:"size:100" json:"token_name"`
+	Group            string          `gorm:"column:group_name;size:50" json:"group"`
+	PromptTokens     int64           `json:"prompt_tokens"`
+	CompletionTokens int64           `json:"completion_tokens"`
+	Quota            int64           `json:"quota"`
+	Cost             decimal.Decimal `gorm:"type:decimal(20,6);default:0" json:"cost"`
+	UseTime          int             `json:"use_time"`
+	IsStream         bool            `json:"is_stream"`
+	LogDate          string          `gorm:"size:10;index:idx_usage_account_date" json:"log_date"`
+	LoggedAt         carbon.DateTime `gorm:"index" json:"logged_at" swaggertype:"string" format:"date-time"`
+	CreatedAt        carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
+// UsageCursor 记录每个账号已同步到的上游日志位置。
+type UsageCursor struct {
+	AccountID     uint             `gorm:"primarykey" json:"account_id"`
+	LastLogID     int64            `json:"last_log_id"`
+	LastTimestamp int64            `json:"last_timestamp"`
+	LastSyncAt    *carbon.DateTime `json:"last_sync_at" swaggertype:"string" format:"date-time"`
+	LastError     string           `gorm:"size:255" json:"last_error"`
+}
+
+// TopupRecord 为一次兑换码充值的结果，Code 仅保存脱敏后的值。
+type TopupRecord struct {
+	ID         uint            `gorm:"primarykey" json:"id"`
+	AccountID  uint            `gorm:"index" json:"account_id"`
+	Code       string          `gorm:"size:64" json:"code"`
+	Success    bool            `json:"success"`
+	Message    string          `gorm:"size:255" json:"message"`
+	QuotaAdded int64           `json:"quota_added"`
+	Amount     decimal.Decimal `gorm:"type:decimal(20,2);default:0" json:"amount"`
+	CreatedAt  carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
+// BalanceHistory 记录账号余额的变化，Source 为变化来源（如 topup）。
+type BalanceHistory struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	AccountID uint            `gorm:"index" json:"account_id"`
+	Source    string          `gorm:"size:20" json:"source"`
+	Change    decimal.Decimal `gorm:"type:decimal(20,2);default:0" json:"change"`
+	Balance   decimal.Decimal `gorm:"type:decimal(20,2);default:0" json:"balance"`
+	Note      string          `gorm:"size:255" json:"note"`
+	CreatedAt carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
+}
+
+// RelayKey 为本系统签发给调用方的中转 API Key，仅保存 SHA-256 摘要。
+type RelayKey struct {
+	ID         uint             `gorm:"primarykey" json:"id"`
+	Name       string           `gorm:"size:100" json:"name"`
+	KeyHash    string           `gorm:"uniqueIndex;size:64" json:"-"`
+	KeyPrefix  string           `gorm:"size:20" json:"key_prefix"`
+	Status     int              `gorm:"default:1" json:"status"`
+	LastUsedAt *carbon.DateTime `json:"last_used_at" swaggertype:"string" format:"date-time"`
+	CreatedAt  carbon.DateTime  `json:"created_at" swaggertype:"string" format:"date-time"`
+	UpdatedAt  carbon.DateTime  `json:"updated_at" swaggertype:"string" format:"date-time"`
+}
+
+// RelayChannel 为中转池中的一个上游令牌。Status：1 启用，0 停用，2 因鉴权失败自动停用。
+type RelayChannel struct {
+	ID         uint             `gorm:"primarykey" json:"id"`
+	AccountID  uint             `gorm:"index" json:"account_id"`
+	TokenID    int              `json:"token_id"`
+	Name       string           `gorm:"size:100" json:"name"`
+	Key        string           `gorm:"type:text" json:"-"`
+	Weight     int              `gorm:"default:1" json:"weight"`
+	Status     int              `gorm:"default:1" json:"status"`
+	LastError  string           `gorm:"size:255" json:"last_error"`
+	LastUsedAt *carbon.DateTime `json:"last_used_at" swaggertype:"string" format:"date-time"`
+	CreatedAt  carbon.DateTime  `json:"created_at" swaggertype:"string" format:"date-time"`
+	UpdatedAt  carbon.DateTime  `json:"updated_at" swaggertype:"string" format:"date-time"`
+}
+
+// RelayLog 记录一次中转请求，用于按调用方 Key 统计用量。
+type RelayLog struct {
+	ID               uint            `gorm:"primarykey" json:"id"`
+	RelayKeyID       uint            `gorm:"index" json:"relay_key_id"`
+	ChannelID        uint            `gorm:"index" json:"channel_id"`
+	AccountID        uint            `gorm:"index" json:"account_id"`
+	Model            string          `gorm:"size:100;index" json:"model"`
+	Path             string          `gorm:"size:255" json:"path"`
+	StatusCode       int             `json:"status_code"`
+	Attempts         int             `json:"attempts"`
+	IsStream         bool            `json:"is_stream"`
+	PromptTokens     int64           `json:"prompt_tokens"`
+	CompletionTokens int64           `json:"completion_tokens"`
+	LatencyMs        int64           `json:"latency_ms"`
+	LogDate          string          `gorm:"size:10;index" json:"log_date"`
+	CreatedAt        carbon.DateTime `json:"created_at" swaggertype:"string" format:"date-time"`
+}
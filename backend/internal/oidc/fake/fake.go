@@ -0,0 +1,276 @@
+// Package fake 提供模拟 OpenID Connect 身份提供方的 HTTP 服务，用于本地联调与集成测试。
+//
+// 支持发现文档、/authorize（自动同意，按 login_hint 选择用户）、/token（校验 PKCE）与 /jwks，
+// ID Token 使用 RS256 签名并携带 preferred_username、email 与 groups 声明。
+package fake
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	keyID = "fake-oidc"
+	// codeTTLMinutes 为授权码的有效分钟数。
+	codeTTLMinutes = 1
+)
+
+type User struct {
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+type authCode struct {
+	username      string
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     *carbon.Carbon
+}
+
+// Server 为模拟身份提供方，零值不可用，请使用 New 创建。
+type Server struct {
+	mu           sync.Mutex
+	key          *rsa.PrivateKey
+	issuer       string
+	clientID     string
+	clientSecret string
+	users        map[string]*User
+	codes        map[string]*authCode
+}
+
+// New 创建模拟身份提供方，clientSecret 为空时不校验客户端密钥。
+func New(clientID, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return &Server{
+		key:          key,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		users:        make(map[string]*User),
+		codes:        make(map[string]*authCode),
+	}
+}
+
+// Start 启动 httptest 服务并以其地址作为 issuer，调用方负责 Close。
+func (s *Server) Start() *httptest.Server {
+	ts := httptest.NewServer(s)
+	s.SetIssuer(ts.URL)
+	return ts
+}
+
+// SetIssuer 设置签发方，需与客户端访问本服务的地址一致。
+func (s *Server) SetIssuer(issuer string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issuer = strings.TrimRight(issuer, "/")
+}
+
+// AddUser 创建或替换用户，subject 由用户名生成。
+func (s *Server) AddUser(username string, groups ...string) User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := &User{
+		Subject:  "sub-" + username,
+		Username: username,
+		Email:    username + "@example.com",
+		Groups:   groups,
+	}
+	s.users[username] = user
+	return *user
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		s.handleDiscovery(w)
+	case "/authorize":
+		s.handleAuthorize(w, r)
+	case "/token":
+		s.handleToken(w, r)
+	case "/jwks":
+		s.handleJWKS(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter) {
+	s.mu.Lock()
+	issuer := s.issuer
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// handleAuthorize 自动同意授权：按 login_hint 选择用户，未指定时使用用户名排序后的第一个用户。
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("client_id") != s.clientID || query.Get("response_type") != "code" ||
+		query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	username := query.Get("login_hint")
+	if username == "" {
+		names := make([]string, 0, len(s.users))
+		for name := range s.users {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			username = names[0]
+		}
+	}
+	_, ok := s.users[username]
+	code := randomString()
+	if ok {
+		s.codes[code] = &authCode{
+			username:      username,
+			clientID:      query.Get("client_id"),
+			redirectURI:   redirectURI.String(),
+			nonce:         query.Get("nonce"),
+			codeChallenge: query.Get("code_challenge"),
+			expiresAt:     carbon.Now().AddMinutes(codeTTLMinutes),
+		}
+	}
+	s.mu.Unlock()
+
+	values := redirectURI.Query()
+	if ok {
+		values.Set("code", code)
+	} else {
+		values.Set("error", "access_denied")
+		values.Set("error_description", "unknown user")
+	}
+	values.Set("state", query.Get("state"))
+	redirectURI.RawQuery = values.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+	if clientID != s.clientID || (s.clientSecret != "" && clientSecret != s.clientSecret) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	if !ok || carbon.Now().Gt(code.expiresAt) || code.clientID != clientID ||
+		code.redirectURI != r.PostForm.Get("redirect_uri") || challenge(r.PostForm.Get("code_verifier")) != code.codeChallenge {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	user := s.users[code.username]
+
+	now := carbon.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                s.issuer,
+		"sub":                user.Subject,
+		"aud":                clientID,
+		"iat":                now.Timestamp(),
+		"exp":                now.AddHour().Timestamp(),
+		"nonce":              code.nonce,
+		"preferred_username": user.Username,
+		"email":              user.Email,
+		"groups":             user.Groups,
+	})
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter) {
+	public := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
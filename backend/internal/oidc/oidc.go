@@ -0,0 +1,384 @@
+// Package oidc 实现 OpenID Connect 授权码流程（PKCE）的客户端：读取发现文档、换取令牌并校验 ID Token 签名与声明。
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// discoveryTTLMinutes 为发现文档的缓存分钟数，keysRefreshMinutes 为重新拉取 JWKS 的最小间隔。
+	discoveryTTLMinutes = 60
+	keysRefreshMinutes  = 1
+	maxResponseSize     = 1 << 20
+)
+
+var (
+	ErrUnknownKey = errors.New("ID Token 签名密钥未知")
+	ErrNonce      = errors.New("ID Token nonce 不匹配")
+)
+
+// Error 为身份提供方返回的 OAuth 错误。
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("身份提供方返回错误: %s (%s)", e.Code, e.Description)
+	}
+	return "身份提供方返回错误: " + e.Code
+}
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Discovery 为 /.well-known/openid-configuration 中使用到的字段。
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims 为 ID Token 的声明。
+type Claims jwt.MapClaims
+
+// String 返回字符串类型的声明，不存在或类型不符时返回空字符串。
+func (c Claims) String(name string) string {
+	value, _ := c[name].(string)
+	return value
+}
+
+// Strings 返回字符串数组类型的声明，兼容以空格或逗号分隔的字符串。
+func (c Claims) Strings(name string) []string {
+	switch value := c[name].(type) {
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				items = append(items, s)
+			}
+		}
+		return items
+	case string:
+		return strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+	return nil
+}
+
+// Provider 为一个身份提供方的客户端，发现文档与签名公钥会被缓存。
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *Discovery
+	discoveredAt  *carbon.Carbon
+	keys          map[string]any
+	keysFetchedAt *carbon.Carbon
+}
+
+func NewProvider(cfg Config) *Provider {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *Provider) Config() Config {
+	return p.cfg
+}
+
+// AuthCodeURL 返回跳转到身份提供方授权页的地址，verifier 为 PKCE 的 code_verifier，loginHint 可为空。
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier, loginHint string) (string, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	if loginHint != "" {
+		query.Set("login_hint", loginHint)
+	}
+
+	endpoint := discovery.AuthorizationEndpoint
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + query.Encode(), nil
+	}
+	return endpoint + "?" + query.Encode(), nil
+}
+
+// Exchange 使用授权码与 code_verifier 换取令牌，返回其中的 ID Token。
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(req, &token); err != nil {
+		return "", err
+	}
+	if token.IDToken == "" {
+		return "", errors.New("身份提供方未返回 id_token，请确认 scopes 包含 openid")
+	}
+	return token.IDToken, nil
+}
+
+// VerifyIDToken 校验 ID Token 的签名、签发方、受众、有效期与 nonce。
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, ErrNonce
+	}
+	return Claims(claims), nil
+}
+
+// Discover 读取并缓存发现文档，签发方需与配置的 issuer 一致。
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	if p.discovery != nil && carbon.Now().Lt(p.discoveredAt.AddMinutes(discoveryTTLMinutes)) {
+		discovery := p.discovery
+		p.mu.Unlock()
+		return discovery, nil
+	}
+	p.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery Discovery
+	if err := p.do(req, &discovery); err != nil {
+		return nil, fmt.Errorf("读取 OIDC 发现文档失败: %w", err)
+	}
+	if strings.TrimRight(discovery.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC 发现文档的 issuer 不匹配: %s", discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("OIDC 发现文档缺少必要的端点")
+	}
+
+	p.mu.Lock()
+	p.discovery = &discovery
+	p.discoveredAt = carbon.Now()
+	p.mu.Unlock()
+	return &discovery, nil
+}
+
+// publicKey 返回 kid 对应的公钥，未知 kid 时重新拉取 JWKS（至多每分钟一次），以支持密钥轮换。
+func (p *Provider) publicKey(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	key, ok := p.lookupKeyLocked(kid)
+	stale := p.keysFetchedAt == nil || carbon.Now().Gte(p.keysFetchedAt.AddMinutes(keysRefreshMinutes))
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, ErrUnknownKey
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.keysFetchedAt = carbon.Now()
+	if key, ok := p.lookupKeyLocked(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// lookupKeyLocked 按 kid 查找公钥；令牌未携带 kid 且只有一个公钥时使用该公钥。
+func (p *Provider) lookupKeyLocked(kid string) (any, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]any, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.do(req, &set); err != nil {
+		return nil, fmt.Errorf("读取 JWKS 失败: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func parseJWK(jwk jsonWebKey) (any, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型: %s", jwk.Kty)
+}
+
+func (p *Provider) do(req *http.Request, out any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr Error
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Code != "" {
+			return &oauthErr
+		}
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// RandomString 返回 URL 安全的随机字符串，用于 state、nonce 与 code_verifier。
+func RandomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CodeChallenge 返回 code_verifier 的 S256 code_challenge。
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
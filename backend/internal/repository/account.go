@@ -10,6 +10,15 @@ func ListAccounts() ([]model.Account, error) {
 	return accounts, nil
 }
 
+// ListAccountsInScope 返回 scope 范围内的账号。
+func ListAccountsInScope(scope OwnerScope) ([]model.Account, error) {
+	var accounts []model.Account
+	if err := scope.applyOwner(DB).Order("id desc").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
 func GetAccountByID(id uint) (*model.Account, error) {
 	var account model.Account
 	if err := DB.First(&account, id).Error; err != nil {
@@ -26,6 +35,10 @@ func SaveAccount(account *model.Account) error {
 	return DB.Save(account).Error
 }
 
+func UpdateAccountSession(id uint, session string) error {
+	return DB.Model(&model.Account{}).Where("id = ?", id).Update("session", session).Error
+}
+
 func DeleteAccount(id uint) error {
 	return DB.Delete(&model.Account{}, id).Error
 }
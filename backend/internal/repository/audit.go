@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"anyrouter-checkin/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter 为审计记录的查询条件，Since 与 Before 为 YYYY-MM-DD，分别为起始日期（含）与截止日期（不含）。
+type AuditFilter struct {
+	ActorName  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      string
+	Before     string
+}
+
+func CreateAuditEvent(event *model.AuditEvent) error {
+	return DB.Create(event).Error
+}
+
+func ListAuditEvents(filter AuditFilter, page, size int) ([]model.AuditEvent, int64, error) {
+	query := applyAuditFilter(DB.Model(&model.AuditEvent{}), filter)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []model.AuditEvent
+	if err := query.Order("id desc").Offset((page - 1) * size).Limit(size).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func applyAuditFilter(query *gorm.DB, filter AuditFilter) *gorm.DB {
+	if filter.ActorName != "" {
+		query = query.Where("actor_name = ?", filter.ActorName)
+	}
+	if filter.Action != "" {
+		query = query.Where("action LIKE ?", "%"+filter.Action+"%")
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Since != "" {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if filter.Before != "" {
+		query = query.Where("created_at < ?", filter.Before)
+	}
+	return query
+}
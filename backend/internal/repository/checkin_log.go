@@ -18,9 +18,9 @@ func GetLatestSuccessfulCheckinLog() (*model.CheckinLog, error) {
 	return &log, nil
 }
 
-func ListCheckinLogs(limit int) ([]model.CheckinLog, error) {
+func ListCheckinLogs(scope OwnerScope, limit int) ([]model.CheckinLog, error) {
 	var logs []model.CheckinLog
-	query := DB.Order("id desc")
+	query := scope.applyAccount(DB, "account_id").Order("id desc")
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
@@ -30,9 +30,9 @@ func ListCheckinLogs(limit int) ([]model.CheckinLog, error) {
 	return logs, nil
 }
 
-func CountSuccessfulAccounts(start, end time.Time) (int64, error) {
+func CountSuccessfulAccounts(scope OwnerScope, start, end time.Time) (int64, error) {
 	var count int64
-	if err := DB.Model(&model.CheckinLog{}).
+	if err := scope.applyAccount(DB.Model(&model.CheckinLog{}), "account_id").
 		Distinct("account_id").
 		Where("success = ?", true).
 		Where("created_at >= ? AND created_at <= ?", start, end).
@@ -6,6 +6,7 @@ import (
 	"anyrouter-checkin/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func ListConfigs(category string) ([]model.Config, error) {
@@ -16,24 +17,104 @@ func ListConfigs(category string) ([]model.Config, error) {
 	return configs, nil
 }
 
+func ListAllConfigs() ([]model.Config, error) {
+	var configs []model.Config
+	if err := DB.Order(clause.OrderByColumn{Column: clause.Column{Name: "key"}}).Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
 func GetConfigValue(key string) (string, error) {
 	var cfg model.Config
-	if err := DB.Where("`key` = ?", key).First(&cfg).Error; err != nil {
+	if err := DB.Where(keyEq(key)).First(&cfg).Error; err != nil {
 		return "", err
 	}
 	return cfg.Value, nil
 }
 
-func SetConfigValue(key, value, category string) error {
-	var cfg model.Config
-	result := DB.Where("`key` = ?", key).First(&cfg)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			cfg = model.Config{Key: key, Value: value, Category: category}
-			return DB.Create(&cfg).Error
+// ConfigVersionFilter 为配置变更记录的查询条件。
+type ConfigVersionFilter struct {
+	Key      string
+	Category string
+}
+
+// SetConfigValues 在同一事务中保存多个配置，不存在的配置项会被创建；
+// 值发生变化的配置按 version 的来源与操作人写入一条变更记录，返回写入的变更记录。
+func SetConfigValues(configs []model.Config, version model.ConfigVersion) ([]model.ConfigVersion, error) {
+	var changes []model.ConfigVersion
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, c := range configs {
+			var cfg model.Config
+			err := tx.Where(keyEq(c.Key)).First(&cfg).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				cfg = model.Config{Key: c.Key, Category: c.Category}
+			case err != nil:
+				return err
+			case cfg.Value == c.Value:
+				continue
+			}
+
+			record := version
+			record.Key = c.Key
+			record.Category = c.Category
+			record.OldValue = cfg.Value
+			record.NewValue = c.Value
+			cfg.Value = c.Value
+			if err := tx.Save(&cfg).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+			changes = append(changes, record)
 		}
-		return result.Error
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func GetConfigVersion(id uint) (*model.ConfigVersion, error) {
+	var version model.ConfigVersion
+	if err := DB.First(&version, id).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func ListConfigVersions(filter ConfigVersionFilter, page, size int) ([]model.ConfigVersion, int64, error) {
+	query := applyConfigVersionFilter(DB.Model(&model.ConfigVersion{}), filter)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var versions []model.ConfigVersion
+	if err := query.Order("id desc").Offset((page - 1) * size).Limit(size).Find(&versions).Error; err != nil {
+		return nil, 0, err
+	}
+	return versions, total, nil
+}
+
+// ListConfigVersionsFrom 按时间顺序返回 ID 不小于 fromID 的变更记录。
+func ListConfigVersionsFrom(fromID uint, filter ConfigVersionFilter) ([]model.ConfigVersion, error) {
+	var versions []model.ConfigVersion
+	query := applyConfigVersionFilter(DB.Where("id >= ?", fromID), filter)
+	if err := query.Order("id").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func applyConfigVersionFilter(query *gorm.DB, filter ConfigVersionFilter) *gorm.DB {
+	if filter.Key != "" {
+		query = query.Where(keyEq(filter.Key))
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
 	}
-	cfg.Value = value
-	return DB.Save(&cfg).Error
+	return query
 }
@@ -10,6 +10,15 @@ func ListCronTasks() ([]model.CronTask, error) {
 	return tasks, nil
 }
 
+// ListCronTasksInScope 返回 scope 范围内的定时任务。
+func ListCronTasksInScope(scope OwnerScope) ([]model.CronTask, error) {
+	var tasks []model.CronTask
+	if err := scope.applyOwner(DB).Order("id desc").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
 func ListEnabledCronTasks() ([]model.CronTask, error) {
 	var tasks []model.CronTask
 	if err := DB.Where("status = ?", 1).Find(&tasks).Error; err != nil {
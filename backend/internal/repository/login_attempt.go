@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"anyrouter-checkin/internal/model"
+
+	"github.com/dromara/carbon/v2"
+)
+
+func CreateLoginAttempt(attempt *model.LoginAttempt) error {
+	return DB.Create(attempt).Error
+}
+
+func ListLoginAttempts(username, ip string, limit int) ([]model.LoginAttempt, error) {
+	var attempts []model.LoginAttempt
+	query := DB.Order("id desc")
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// DeleteLoginAttemptsBefore 清理早于 before 的失败记录。
+func DeleteLoginAttemptsBefore(before *carbon.Carbon) error {
+	return DB.Where("created_at < ?", carbon.DateTime{Carbon: before}).Delete(&model.LoginAttempt{}).Error
+}
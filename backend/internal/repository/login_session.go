@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"anyrouter-checkin/internal/model"
+
+	"github.com/dromara/carbon/v2"
+	"gorm.io/gorm"
+)
+
+func CreateLoginSession(session *model.LoginSession) error {
+	return DB.Create(session).Error
+}
+
+func GetLoginSession(id uint) (*model.LoginSession, error) {
+	var session model.LoginSession
+	if err := DB.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func GetLoginSessionByRefreshHash(hash string) (*model.LoginSession, error) {
+	var session model.LoginSession
+	if err := DB.Where("refresh_hash = ?", hash).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func GetLoginSessionByPreviousHash(hash string) (*model.LoginSession, error) {
+	var session model.LoginSession
+	if err := DB.Where("previous_hash = ?", hash).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateLoginSession 仅当刷新令牌仍为 oldHash 时替换为 newHash，返回是否替换成功，避免并发刷新同时成功。
+func RotateLoginSession(id uint, oldHash, newHash, ip string, expiresAt *carbon.Carbon) (bool, error) {
+	result := DB.Model(&model.LoginSession{}).
+		Where("id = ? AND refresh_hash = ?", id, oldHash).
+		UpdateColumns(map[string]interface{}{
+			"refresh_hash":  newHash,
+			"previous_hash": oldHash,
+			"ip":            ip,
+			"expires_at":    carbon.DateTime{Carbon: expiresAt},
+			"last_used_at":  carbon.DateTime{Carbon: carbon.Now()},
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func ListLoginSessions(userID uint) ([]model.LoginSession, error) {
+	var sessions []model.LoginSession
+	if err := DB.Where("user_id = ?", userID).Order("id desc").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// DeleteLoginSession 删除用户自己的会话，返回是否存在。
+func DeleteLoginSession(userID, id uint) (bool, error) {
+	result := DB.Where("id = ? AND user_id = ?", id, userID).Delete(&model.LoginSession{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteUserLoginSessions 删除用户除 exceptID 外的全部会话，exceptID 为 0 时全部删除。
+func DeleteUserLoginSessions(userID, exceptID uint) error {
+	return DB.Where("user_id = ? AND id <> ?", userID, exceptID).Delete(&model.LoginSession{}).Error
+}
+
+func DeleteLoginSessionsExpiredBefore(before *carbon.Carbon) error {
+	return DB.Where("expires_at < ?", carbon.DateTime{Carbon: before}).Delete(&model.LoginSession{}).Error
+}
+
+// UpdateUserPassword 在同一事务中更新用户密码、递增令牌版本并删除其全部会话，
+// 已签发的访问令牌与刷新令牌随之失效；任一步失败时密码保持不变。
+func UpdateUserPassword(userID uint, password string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Update("password", password).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.LoginSession{}).Error
+	})
+}
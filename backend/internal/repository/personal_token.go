@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"anyrouter-checkin/internal/model"
+
+	"github.com/dromara/carbon/v2"
+)
+
+func ListPersonalTokens(userID uint) ([]model.PersonalToken, error) {
+	var tokens []model.PersonalToken
+	if err := DB.Where("user_id = ?", userID).Order("id desc").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func GetPersonalTokenByHash(hash string) (*model.PersonalToken, error) {
+	var token model.PersonalToken
+	if err := DB.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func CreatePersonalToken(token *model.PersonalToken) error {
+	return DB.Create(token).Error
+}
+
+func TouchPersonalToken(id uint) error {
+	return DB.Model(&model.PersonalToken{}).Where("id = ?", id).
+		UpdateColumn("last_used_at", carbon.DateTime{Carbon: carbon.Now()}).Error
+}
+
+// DeletePersonalToken 删除用户自己的令牌，返回是否存在。
+func DeletePersonalToken(userID, id uint) (bool, error) {
+	result := DB.Where("id = ? AND user_id = ?", id, userID).Delete(&model.PersonalToken{})
+	return result.RowsAffected > 0, result.Error
+}
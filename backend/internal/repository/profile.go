@@ -0,0 +1,39 @@
+package repository
+
+import "anyrouter-checkin/internal/model"
+
+func ListBrowserProfiles() ([]model.BrowserProfile, error) {
+	var profiles []model.BrowserProfile
+	if err := DB.Order("id asc").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func GetBrowserProfileByID(id uint) (*model.BrowserProfile, error) {
+	var profile model.BrowserProfile
+	if err := DB.First(&profile, id).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func CreateBrowserProfile(profile *model.BrowserProfile) error {
+	return DB.Create(profile).Error
+}
+
+func SaveBrowserProfile(profile *model.BrowserProfile) error {
+	return DB.Save(profile).Error
+}
+
+func DeleteBrowserProfile(id uint) error {
+	return DB.Delete(&model.BrowserProfile{}, id).Error
+}
+
+func CountAccountsByProfile(profileID uint) (int64, error) {
+	var count int64
+	if err := DB.Model(&model.Account{}).Where("profile_id = ?", profileID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
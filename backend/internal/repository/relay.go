@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"anyrouter-checkin/internal/model"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// RelayStat 为按日期与调用方 Key、账号或模型聚合的中转用量。
+type RelayStat struct {
+	LogDate          string `json:"log_date"`
+	RelayKeyID       uint   `json:"relay_key_id,omitempty"`
+	AccountID        uint   `json:"account_id,omitempty"`
+	Model            string `json:"model,omitempty"`
+	Requests         int64  `json:"requests"`
+	Failures         int64  `json:"failures"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+}
+
+func ListRelayKeys() ([]model.RelayKey, error) {
+	var keys []model.RelayKey
+	if err := DB.Order("id desc").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func GetRelayKeyByID(id uint) (*model.RelayKey, error) {
+	var key model.RelayKey
+	if err := DB.First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func GetRelayKeyByHash(hash string) (*model.RelayKey, error) {
+	var key model.RelayKey
+	if err := DB.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func CreateRelayKey(key *model.RelayKey) error {
+	return DB.Create(key).Error
+}
+
+func SaveRelayKey(key *model.RelayKey) error {
+	return DB.Save(key).Error
+}
+
+func TouchRelayKey(id uint) error {
+	return DB.Model(&model.RelayKey{}).Where("id = ?", id).
+		UpdateColumn("last_used_at", carbon.DateTime{Carbon: carbon.Now()}).Error
+}
+
+func DeleteRelayKey(id uint) error {
+	return DB.Delete(&model.RelayKey{}, id).Error
+}
+
+func ListRelayChannels() ([]model.RelayChannel, error) {
+	var channels []model.RelayChannel
+	if err := DB.Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func GetRelayChannelByID(id uint) (*model.RelayChannel, error) {
+	var channel model.RelayChannel
+	if err := DB.First(&channel, id).Error; err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func CreateRelayChannel(channel *model.RelayChannel) error {
+	return DB.Create(channel).Error
+}
+
+func SaveRelayChannel(channel *model.RelayChannel) error {
+	return DB.Save(channel).Error
+}
+
+func UpdateRelayChannelColumns(id uint, values map[string]interface{}) error {
+	return DB.Model(&model.RelayChannel{}).Where("id = ?", id).UpdateColumns(values).Error
+}
+
+func DeleteRelayChannel(id uint) error {
+	return DB.Delete(&model.RelayChannel{}, id).Error
+}
+
+func DeleteRelayChannelsByAccount(accountID uint) error {
+	return DB.Where("account_id = ?", accountID).Delete(&model.RelayChannel{}).Error
+}
+
+func CreateRelayLog(log *model.RelayLog) error {
+	return DB.Create(log).Error
+}
+
+// SumRelayUsage 按日期聚合中转用量，groupBy 为 "key"、"account" 或 "model"。
+func SumRelayUsage(startDate, endDate, groupBy string) ([]RelayStat, error) {
+	column := "relay_key_id"
+	switch groupBy {
+	case "account":
+		column = "account_id"
+	case "model":
+		column = "model"
+	}
+	query := DB.Model(&model.RelayLog{})
+	if startDate != "" {
+		query = query.Where("log_date >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where("log_date <= ?", endDate)
+	}
+	var stats []RelayStat
+	err := query.
+		Select("log_date, " + column + ", COUNT(*) AS requests, SUM(CASE WHEN status_code >= 400 THEN 1 ELSE 0 END) AS failures, SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens").
+		Group("log_date, " + column).
+		Order("log_date, " + column).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
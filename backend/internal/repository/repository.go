@@ -2,64 +2,112 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 
+	"anyrouter-checkin/internal/config"
 	"anyrouter-checkin/internal/model"
 
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-func Init(dbPath string) error {
-	var err error
-	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+// migrateModels 为启动时自动迁移的表。
+var migrateModels = []any{
+	&model.User{},
+	&model.Team{},
+	&model.PersonalToken{},
+	&model.LoginSession{},
+	&model.LoginAttempt{},
+	&model.AuditEvent{},
+	&model.Account{},
+	&model.CronTask{},
+	&model.Config{},
+	&model.ConfigVersion{},
+	&model.CheckinLog{},
+	&model.BrowserProfile{},
+	&model.UsageRecord{},
+	&model.UsageCursor{},
+	&model.TopupRecord{},
+	&model.BalanceHistory{},
+	&model.RelayKey{},
+	&model.RelayChannel{},
+	&model.RelayLog{},
+}
+
+// Init 按配置的驱动连接数据库、设置连接池并迁移表结构。
+func Init(cfg config.DatabaseConfig) error {
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return err
+	}
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
 		return err
 	}
 
-	if err := DB.AutoMigrate(
-		&model.User{},
-		&model.Account{},
-		&model.CronTask{},
-		&model.Config{},
-		&model.CheckinLog{},
-	); err != nil {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	if err := DB.AutoMigrate(migrateModels...); err != nil {
 		return err
 	}
 	return nil
 }
 
-func InitDefaultConfigs() {
-	defaultTelegramTemplate := `<b>AnyRouter 签到系统</b>
-用户名：<code>{{.Username}}</code>
-状态：{{if .Success}}<b>成功 ✅</b>{{else}}<b>失败 ❌</b>{{end}}
-结果：
-<pre>{{.Result}}</pre>`
-	defaults := []model.Config{
-		{Key: "telegram.api_base", Value: "https://api.telegram.org", Category: "telegram"},
-		{Key: "telegram.bot_token", Value: "", Category: "telegram"},
-		{Key: "telegram.chat_id", Value: "", Category: "telegram"},
-		{Key: "telegram.enabled", Value: "false", Category: "telegram"},
-		{Key: "telegram.proxy_url", Value: "", Category: "telegram"},
-		{Key: "telegram.template", Value: defaultTelegramTemplate, Category: "telegram"},
+func openDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "", "sqlite":
+		return sqlite.Open(cfg.Path), nil
+	case "postgres":
+		return postgres.Open(cfg.DSN), nil
+	case "mysql":
+		return mysql.Open(cfg.DSN), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
 	}
+}
+
+// keyEq 为 key 列的等值条件，key 在 MySQL 中为保留字，由方言负责转义。
+func keyEq(key string) clause.Eq {
+	return clause.Eq{Column: clause.Column{Name: "key"}, Value: key}
+}
+
+// InitDefaultConfigs 写入尚不存在的配置项，已有配置保持不变。
+func InitDefaultConfigs(defaults []model.Config) error {
 	for _, c := range defaults {
 		var cfg model.Config
-		if err := DB.Where("`key` = ?", c.Key).First(&cfg).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				if err := DB.Create(&c).Error; err != nil {
-					continue
-				}
-			}
+		err := DB.Where(keyEq(c.Key)).First(&cfg).Error
+		if err == nil {
 			continue
 		}
-
-		if c.Key == "telegram.template" {
-			continue
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := DB.Create(&c).Error; err != nil {
+			return err
 		}
 	}
+	return nil
 }
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/model"
+
+	"github.com/dromara/carbon/v2"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDatabases 返回要测试的数据库：默认只测试临时 SQLite，设置 ANYROUTER_TEST_POSTGRES_DSN 或
+// ANYROUTER_TEST_MYSQL_DSN 时同时测试对应数据库。测试会删除并重建全部表，只能指向专用的测试库。
+func testDatabases() []struct {
+	name string
+	cfg  config.DatabaseConfig
+} {
+	return []struct {
+		name string
+		cfg  config.DatabaseConfig
+	}{
+		{"sqlite", config.DatabaseConfig{Driver: "sqlite"}},
+		{"postgres", config.DatabaseConfig{Driver: "postgres", DSN: os.Getenv("ANYROUTER_TEST_POSTGRES_DSN")}},
+		{"mysql", config.DatabaseConfig{Driver: "mysql", DSN: os.Getenv("ANYROUTER_TEST_MYSQL_DSN")}},
+	}
+}
+
+// forEachDatabase 在每个可用的数据库上以空表运行 fn，未设置连接串的数据库跳过。
+func forEachDatabase(t *testing.T, fn func(t *testing.T)) {
+	for _, db := range testDatabases() {
+		t.Run(db.name, func(t *testing.T) {
+			cfg := db.cfg
+			if cfg.Driver == "sqlite" {
+				cfg.Path = filepath.Join(t.TempDir(), "app.db")
+			} else if cfg.DSN == "" {
+				t.Skipf("未设置 ANYROUTER_TEST_%s_DSN", map[string]string{"postgres": "POSTGRES", "mysql": "MYSQL"}[cfg.Driver])
+			}
+			if err := Init(cfg); err != nil {
+				t.Fatal(err)
+			}
+			// 外部数据库可能留有上次运行的数据，删除后重新迁移，同时验证从空库建表。
+			if err := DB.Migrator().DropTable(migrateModels...); err != nil {
+				t.Fatal(err)
+			}
+			if err := Init(cfg); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				if sqlDB, err := DB.DB(); err == nil {
+					_ = sqlDB.Close()
+				}
+			})
+			fn(t)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T) {
+		for _, m := range migrateModels {
+			if !DB.Migrator().HasTable(m) {
+				t.Fatalf("缺少表 %T", m)
+			}
+		}
+		// 已有表结构时再次迁移不应报错。
+		if err := DB.AutoMigrate(migrateModels...); err != nil {
+			t.Fatalf("重复迁移失败: %v", err)
+		}
+		if err := DB.Create(&model.Config{Key: "telegram.enabled", Category: "telegram"}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := DB.Create(&model.Config{Key: "telegram.enabled", Category: "telegram"}).Error; err == nil {
+			t.Fatal("config.key 应有唯一索引")
+		}
+	})
+}
+
+func TestConfigKeyLookups(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T) {
+		defaults := []model.Config{
+			{Key: "telegram.enabled", Value: "false", Category: "telegram"},
+			{Key: "relay.strategy", Value: "round_robin", Category: "relay"},
+		}
+		if err := InitDefaultConfigs(defaults); err != nil {
+			t.Fatal(err)
+		}
+		// 已存在的配置项保持不变。
+		if err := InitDefaultConfigs([]model.Config{{Key: "telegram.enabled", Value: "true", Category: "telegram"}}); err != nil {
+			t.Fatal(err)
+		}
+		if value, err := GetConfigValue("telegram.enabled"); err != nil || value != "false" {
+			t.Fatalf("GetConfigValue = %q, %v", value, err)
+		}
+		if _, err := GetConfigValue("telegram.missing"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("不存在的配置项应返回 ErrRecordNotFound: %v", err)
+		}
+
+		changes, err := SetConfigValues([]model.Config{
+			{Key: "telegram.enabled", Value: "true", Category: "telegram"},
+			{Key: "relay.strategy", Value: "round_robin", Category: "relay"},
+			{Key: "telegram.chat_id", Value: "10001", Category: "telegram"},
+		}, model.ConfigVersion{Source: "update", ActorID: 1, ActorName: "admin"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var changed []string
+		for _, c := range changes {
+			changed = append(changed, c.Key+":"+c.OldValue+"->"+c.NewValue)
+		}
+		if want := []string{"telegram.enabled:false->true", "telegram.chat_id:->10001"}; !slices.Equal(changed, want) {
+			t.Fatalf("变更记录 = %v, want %v", changed, want)
+		}
+
+		configs, err := ListAllConfigs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, c := range configs {
+			keys = append(keys, c.Key)
+		}
+		if want := []string{"relay.strategy", "telegram.chat_id", "telegram.enabled"}; !slices.Equal(keys, want) {
+			t.Fatalf("ListAllConfigs 应按 key 排序: %v", keys)
+		}
+
+		versions, total, err := ListConfigVersions(ConfigVersionFilter{Key: "telegram.enabled"}, 1, 10)
+		if err != nil || total != 1 || versions[0].NewValue != "true" || versions[0].ActorName != "admin" {
+			t.Fatalf("按 key 查询变更记录: total=%d versions=%+v err=%v", total, versions, err)
+		}
+		if _, total, err = ListConfigVersions(ConfigVersionFilter{Category: "telegram"}, 1, 10); err != nil || total != 2 {
+			t.Fatalf("按分类查询变更记录: total=%d err=%v", total, err)
+		}
+		from, err := ListConfigVersionsFrom(versions[0].ID, ConfigVersionFilter{Key: "telegram.chat_id"})
+		if err != nil || len(from) != 1 || from[0].Key != "telegram.chat_id" {
+			t.Fatalf("ListConfigVersionsFrom = %+v, %v", from, err)
+		}
+	})
+}
+
+func TestDateTimeRoundTrip(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T) {
+		lastRun := carbon.CreateFromStdTime(time.Date(2026, 3, 1, 8, 30, 15, 0, time.Local))
+		task := model.CronTask{Name: "daily", CronExpr: "0 8 * * *", LastRun: carbon.NewDateTime(lastRun)}
+		if err := CreateCronTask(&task); err != nil {
+			t.Fatal(err)
+		}
+		got, err := GetCronTaskByID(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.LastRun == nil || got.LastRun.Timestamp() != lastRun.Timestamp() {
+			t.Fatalf("last_run 读写不一致: got %v, want %v", got.LastRun, lastRun)
+		}
+		if got.NextRun != nil {
+			t.Fatalf("未设置的 next_run 应读取为 nil: %v", got.NextRun)
+		}
+		if got.CreatedAt.IsZero() || got.CreatedAt.DiffAbsInSeconds(carbon.Now()) > 60 {
+			t.Fatalf("created_at 应在创建时自动填写: %v", got.CreatedAt)
+		}
+
+		// 按时间范围查询依赖数据库对时间列的比较。
+		for _, log := range []model.CheckinLog{
+			{AccountID: 1, Success: true, CreatedAt: carbon.DateTime{Carbon: lastRun}},
+			{AccountID: 2, Success: true, CreatedAt: carbon.DateTime{Carbon: lastRun.AddDay()}},
+			{AccountID: 3, Success: false, CreatedAt: carbon.DateTime{Carbon: lastRun}},
+		} {
+			if err := CreateCheckinLog(&log); err != nil {
+				t.Fatal(err)
+			}
+		}
+		count, err := CountSuccessfulAccounts(OwnerScope{}, lastRun.StartOfDay().StdTime(), lastRun.EndOfDay().StdTime())
+		if err != nil || count != 1 {
+			t.Fatalf("当天成功签到账号数 = %d, %v", count, err)
+		}
+		latest, err := GetLatestSuccessfulCheckinLog()
+		if err != nil || latest.AccountID != 2 || latest.CreatedAt.ToDateTimeString() != lastRun.AddDay().ToDateTimeString() {
+			t.Fatalf("最近一次成功签到 = %+v, %v", latest, err)
+		}
+	})
+}
+
+// key 在 MySQL 中为保留字，按 key 查询与排序须由方言转义；以 DryRun 记录生成的 SQL，无需连接数据库。
+func TestKeyColumnQuoting(t *testing.T) {
+	for _, tc := range []struct {
+		dialector gorm.Dialector
+		key       string
+	}{
+		{mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}), "`key`"},
+		{postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test"}), `"key"`},
+	} {
+		t.Run(tc.dialector.Name(), func(t *testing.T) {
+			db, err := gorm.Open(tc.dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Default.LogMode(logger.Silent)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var statements []string
+			if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+				statements = append(statements, tx.Statement.SQL.String())
+			}); err != nil {
+				t.Fatal(err)
+			}
+			previous := DB
+			DB = db
+			t.Cleanup(func() { DB = previous })
+
+			_, _ = GetConfigValue("telegram.enabled")
+			_, _ = ListAllConfigs()
+			_, _, _ = ListConfigVersions(ConfigVersionFilter{Key: "telegram.enabled"}, 1, 10)
+			if len(statements) != 4 {
+				t.Fatalf("应生成 4 条查询，实际 %d: %v", len(statements), statements)
+			}
+			for _, want := range []string{
+				"WHERE " + tc.key + " = ",
+				"ORDER BY " + tc.key,
+				"WHERE " + tc.key + " = ",
+				"WHERE " + tc.key + " = ",
+			} {
+				statement := statements[0]
+				statements = statements[1:]
+				if !strings.Contains(statement, want) {
+					t.Fatalf("SQL 未转义 key 列，应包含 %q: %s", want, statement)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"slices"
+
+	"anyrouter-checkin/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// OwnerScope 为账号与定时任务的可见范围。零值不做限制；Restricted 为 true 时仅限 OwnerIDs 中用户拥有的数据。
+type OwnerScope struct {
+	Restricted bool
+	OwnerIDs   []uint
+}
+
+// Allows 判断 ownerID 拥有的数据是否在范围内。
+func (s OwnerScope) Allows(ownerID uint) bool {
+	return !s.Restricted || slices.Contains(s.OwnerIDs, ownerID)
+}
+
+// applyOwner 限制带 owner_id 列的表。
+func (s OwnerScope) applyOwner(query *gorm.DB) *gorm.DB {
+	if !s.Restricted {
+		return query
+	}
+	return query.Where("owner_id IN ?", s.OwnerIDs)
+}
+
+// applyAccount 按 column 关联的账号限制查询，用于日志、消费等按账号记录的数据。
+func (s OwnerScope) applyAccount(query *gorm.DB, column string) *gorm.DB {
+	if !s.Restricted {
+		return query
+	}
+	visible := DB.Model(&model.Account{}).Select("id").Where("owner_id IN ?", s.OwnerIDs)
+	return query.Where(column+" IN (?)", visible)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"anyrouter-checkin/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func ListTeams() ([]model.Team, error) {
+	var teams []model.Team
+	if err := DB.Order("id").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func GetTeamByID(id uint) (*model.Team, error) {
+	var team model.Team
+	if err := DB.First(&team, id).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func CreateTeam(team *model.Team) error {
+	return DB.Create(team).Error
+}
+
+func SaveTeam(team *model.Team) error {
+	return DB.Save(team).Error
+}
+
+// DeleteTeam 删除团队并将成员移出。
+func DeleteTeam(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("team_id = ?", id).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Team{}, id).Error
+	})
+}
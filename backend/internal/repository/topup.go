@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"anyrouter-checkin/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func CreateTopupRecord(record *model.TopupRecord) error {
+	return DB.Create(record).Error
+}
+
+func ListTopupRecords(scope OwnerScope, accountID uint, limit int) ([]model.TopupRecord, error) {
+	var records []model.TopupRecord
+	query := scope.applyAccount(DB, "account_id").Order("id desc")
+	if accountID > 0 {
+		query = query.Where("account_id = ?", accountID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func CreateBalanceHistory(history *model.BalanceHistory) error {
+	return DB.Create(history).Error
+}
+
+func ListBalanceHistory(accountID uint, limit int) ([]model.BalanceHistory, error) {
+	var histories []model.BalanceHistory
+	query := DB.Where("account_id = ?", accountID).Order("id desc")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+func DeleteTopupByAccount(accountID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("account_id = ?", accountID).Delete(&model.TopupRecord{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("account_id = ?", accountID).Delete(&model.BalanceHistory{}).Error
+	})
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+
+	"anyrouter-checkin/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UsageFilter 为消费记录的查询条件，日期为 YYYY-MM-DD，空值表示不限。
+type UsageFilter struct {
+	AccountID uint
+	ModelName string
+	StartDate string
+	EndDate   string
+	Scope     OwnerScope
+}
+
+// UsageStat 为按日期与账号、模型或令牌聚合的消费统计，未参与分组的字段为零值。
+type UsageStat struct {
+	LogDate          string `json:"log_date"`
+	AccountID        uint   `json:"account_id,omitempty"`
+	ModelName        string `json:"model_name,omitempty"`
+	TokenName        string `json:"token_name,omitempty"`
+	Requests         int64  `json:"requests"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	Quota            int64  `json:"quota"`
+}
+
+// CreateUsageRecords 批量写入消费记录，已存在的上游日志会被忽略，返回实际新增条数。
+func CreateUsageRecords(records []model.UsageRecord) (int64, error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&records)
+	return result.RowsAffected, result.Error
+}
+
+// GetUsageCursor 返回账号的同步位置，尚未同步过时返回零值游标。
+func GetUsageCursor(accountID uint) (*model.UsageCursor, error) {
+	var cursor model.UsageCursor
+	if err := DB.First(&cursor, "account_id = ?", accountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &model.UsageCursor{AccountID: accountID}, nil
+		}
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func SaveUsageCursor(cursor *model.UsageCursor) error {
+	return DB.Save(cursor).Error
+}
+
+func ListUsageCursors(scope OwnerScope) ([]model.UsageCursor, error) {
+	var cursors []model.UsageCursor
+	if err := scope.applyAccount(DB, "account_id").Order("account_id").Find(&cursors).Error; err != nil {
+		return nil, err
+	}
+	return cursors, nil
+}
+
+func DeleteUsageByAccount(accountID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("account_id = ?", accountID).Delete(&model.UsageRecord{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("account_id = ?", accountID).Delete(&model.UsageCursor{}).Error
+	})
+}
+
+func ListUsageRecords(filter UsageFilter, page, size int) ([]model.UsageRecord, int64, error) {
+	query := applyUsageFilter(DB.Model(&model.UsageRecord{}), filter)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var records []model.UsageRecord
+	if err := query.Order("logged_at desc, id desc").Offset((page - 1) * size).Limit(size).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// SumUsage 按日期聚合消费，groupBy 为 "account"、"model" 或 "token"。
+func SumUsage(filter UsageFilter, groupBy string) ([]UsageStat, error) {
+	column := "account_id"
+	switch groupBy {
+	case "model":
+		column = "model_name"
+	case "token":
+		column = "token_name"
+	}
+	var stats []UsageStat
+	err := applyUsageFilter(DB.Model(&model.UsageRecord{}), filter).
+		Select("log_date, " + column + ", COUNT(*) AS requests, SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, SUM(quota) AS quota").
+		Group("log_date, " + column).
+		Order("log_date, " + column).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func applyUsageFilter(query *gorm.DB, filter UsageFilter) *gorm.DB {
+	query = filter.Scope.applyAccount(query, "account_id")
+	if filter.AccountID > 0 {
+		query = query.Where("account_id = ?", filter.AccountID)
+	}
+	if filter.ModelName != "" {
+		query = query.Where("model_name = ?", filter.ModelName)
+	}
+	if filter.StartDate != "" {
+		query = query.Where("log_date >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("log_date <= ?", filter.EndDate)
+	}
+	return query
+}
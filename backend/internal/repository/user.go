@@ -1,6 +1,10 @@
 package repository
 
-import "anyrouter-checkin/internal/model"
+import (
+	"anyrouter-checkin/internal/model"
+
+	"gorm.io/gorm"
+)
 
 func GetUserByUsername(username string) (*model.User, error) {
 	var user model.User
@@ -30,6 +34,78 @@ func GetUserByID(id uint) (*model.User, error) {
 	return &user, nil
 }
 
+func GetUserByOIDCSubject(subject string) (*model.User, error) {
+	var user model.User
+	if err := DB.Where("oidc_subject = ?", subject).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func SaveUser(user *model.User) error {
 	return DB.Save(user).Error
 }
+
+// UpdateTOTPLastStep 仅当已使用的时间步小于 step 时记录 step，返回是否更新，避免同一动态码被并发重放。
+func UpdateTOTPLastStep(userID uint, step int64) (bool, error) {
+	result := DB.Model(&model.User{}).Where("id = ? AND totp_last_step < ?", userID, step).UpdateColumn("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateRecoveryCodes 仅当恢复码仍为 old 时替换为 codes，返回是否替换，避免同一恢复码被并发使用。
+func UpdateRecoveryCodes(userID uint, old, codes string) (bool, error) {
+	result := DB.Model(&model.User{}).Where("id = ? AND recovery_codes = ?", userID, old).UpdateColumn("recovery_codes", codes)
+	return result.RowsAffected > 0, result.Error
+}
+
+func ListUsers() ([]model.User, error) {
+	var users []model.User
+	if err := DB.Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CountActiveAdmins 统计启用中的管理员数量，可排除指定用户。
+func CountActiveAdmins(excludeID uint) (int64, error) {
+	var count int64
+	if err := DB.Model(&model.User{}).
+		Where("role = ? AND status = 1 AND id <> ?", model.RoleAdmin, excludeID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// BackfillUserRoles 为升级前创建、尚未设置角色的用户补充角色。
+func BackfillUserRoles(role string) error {
+	return DB.Model(&model.User{}).Where("role = '' OR role IS NULL").Update("role", role).Error
+}
+
+// ListUserIDsByTeam 返回团队成员的 ID。
+func ListUserIDsByTeam(teamID uint) ([]uint, error) {
+	var ids []uint
+	if err := DB.Model(&model.User{}).Where("team_id = ?", teamID).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetFirstAdmin 返回最早创建的管理员。
+func GetFirstAdmin() (*model.User, error) {
+	var user model.User
+	if err := DB.Where("role = ?", model.RoleAdmin).Order("id").First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// BackfillOwners 将升级前创建、尚无所有者的账号与定时任务归属到 ownerID。
+func BackfillOwners(ownerID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Account{}).Where("owner_id = 0 OR owner_id IS NULL").Update("owner_id", ownerID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.CronTask{}).Where("owner_id = 0 OR owner_id IS NULL").Update("owner_id", ownerID).Error
+	})
+}
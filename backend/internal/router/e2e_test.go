@@ -0,0 +1,95 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/service"
+	"anyrouter-checkin/internal/upstream/fake"
+)
+
+// TestCheckinEndToEnd 经路由完成添加账号、手动签到、触发定时任务与查询签到日志，
+// 上游为开启 WAF 挑战的模拟站点。
+func TestCheckinEndToEnd(t *testing.T) {
+	upstreamFake := fake.New()
+	upstreamFake.SetChallenge(true)
+	upstreamServer := upstreamFake.Start()
+	defer upstreamServer.Close()
+	const initialQuota = 1000000
+	userID, session := upstreamFake.AddUser("alice", initialQuota)
+
+	s := newTestServer(t, upstreamServer.URL, nil)
+	service.InitCron()
+
+	var account model.Account
+	s.call(http.MethodPost, "/api/accounts", map[string]string{"session": session}, &account)
+	if account.UserID != userID || account.Username != "alice" || account.Status != 1 {
+		t.Fatalf("账号信息与 session 不一致: %+v", account)
+	}
+
+	var checkin struct {
+		Success bool   `json:"success"`
+		Result  string `json:"result"`
+	}
+	s.call(http.MethodPost, fmt.Sprintf("/api/accounts/%d/checkin", account.ID), nil, &checkin)
+	if !checkin.Success {
+		t.Fatalf("签到失败: %s", checkin.Result)
+	}
+	assertQuota(t, upstreamFake, userID, initialQuota+fake.DefaultSignInQuota)
+
+	upstreamFake.ResetSignIn(userID)
+	var task model.CronTask
+	s.call(http.MethodPost, "/api/cron", map[string]any{
+		"name":        "每日签到",
+		"cron_expr":   "0 8 * * *",
+		"task_type":   "checkin",
+		"account_ids": fmt.Sprintf("[%d]", account.ID),
+	}, &task)
+	s.call(http.MethodPost, fmt.Sprintf("/api/cron/%d/trigger", task.ID), nil, nil)
+
+	// 触发后任务在后台执行，完成时写入 last_run。
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var tasks []model.CronTask
+		s.call(http.MethodGet, "/api/cron", nil, &tasks)
+		if len(tasks) == 1 && tasks[0].LastRun != nil {
+			if tasks[0].NextRun == nil {
+				t.Fatal("执行后应计算下次执行时间")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("定时任务未在 10 秒内执行完成")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	assertQuota(t, upstreamFake, userID, initialQuota+2*fake.DefaultSignInQuota)
+
+	var summary service.CheckinLogSummary
+	s.call(http.MethodGet, "/api/logs", nil, &summary)
+	if len(summary.Logs) != 2 {
+		t.Fatalf("应有手动与定时各一条签到日志，实际 %d 条", len(summary.Logs))
+	}
+	for _, log := range summary.Logs {
+		if log.AccountID != account.ID || !log.Success {
+			t.Fatalf("签到日志不正确: %+v", log)
+		}
+	}
+	if summary.TodayCheckinAccountCount != 1 {
+		t.Fatalf("今日签到账号数 = %d, want 1", summary.TodayCheckinAccountCount)
+	}
+}
+
+func assertQuota(t *testing.T, upstreamFake *fake.Server, userID int, want int64) {
+	t.Helper()
+	user, ok := upstreamFake.User(userID)
+	if !ok {
+		t.Fatalf("模拟上游不存在用户 %d", userID)
+	}
+	if user.Quota != want {
+		t.Fatalf("quota = %d, want %d", user.Quota, want)
+	}
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"fmt"
+	"testing"
+
+	"anyrouter-checkin/internal/config"
+)
+
+// 登录请求经本机 Nginx 转发时，按 IP 的锁定应针对 X-Forwarded-For 中的真实客户端，
+// 而不是 Nginx 的地址，否则任何人都能锁住所有用户的登录。
+func TestLoginIPLockoutBehindProxy(t *testing.T) {
+	const nginx = "127.0.0.1:41234"
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantAdminCode  int
+	}{
+		{name: "trusted", trustedProxies: []string{"127.0.0.1", "::1"}, wantAdminCode: 0},
+		// 未信任代理时所有请求共用 Nginx 的地址，攻击者的失败会锁住管理员。
+		{name: "untrusted", trustedProxies: nil, wantAdminCode: 429},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, "http://127.0.0.1:1", func(cfg *config.Config) {
+				cfg.Server.TrustedProxies = tt.trustedProxies
+			})
+
+			// 每次使用不同用户名，只触发按 IP 的锁定。
+			for i := 0; i < 20; i++ {
+				if code := s.login(nginx, "198.51.100.7", fmt.Sprintf("nobody%d", i), "wrong"); code != 401 && code != 429 {
+					t.Fatalf("第 %d 次失败登录返回 code %d", i+1, code)
+				}
+			}
+			if code := s.login(nginx, "198.51.100.7", "admin", testAdminPassword); code != 429 {
+				t.Fatalf("攻击者 IP 应被锁定，实际 code %d", code)
+			}
+			if code := s.login(nginx, "203.0.113.5", "admin", testAdminPassword); code != tt.wantAdminCode {
+				t.Fatalf("管理员登录 code = %d, want %d", code, tt.wantAdminCode)
+			}
+		})
+	}
+}
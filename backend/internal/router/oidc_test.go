@@ -0,0 +1,149 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"anyrouter-checkin/internal/config"
+	oidcfake "anyrouter-checkin/internal/oidc/fake"
+	"anyrouter-checkin/internal/service"
+
+	"github.com/dromara/carbon/v2"
+)
+
+const oidcTestRedirectURL = "http://app.test/api/auth/oidc/callback"
+
+func newOIDCTestServer(t *testing.T) *testServer {
+	t.Helper()
+	idp := oidcfake.New("anyrouter-checkin", "")
+	idpServer := idp.Start()
+	t.Cleanup(idpServer.Close)
+	idp.AddUser("attacker", "anyrouter-admins")
+	idp.AddUser("victim", "anyrouter-admins")
+
+	return newTestServer(t, "http://127.0.0.1:1", func(cfg *config.Config) {
+		cfg.OIDC = config.OIDCConfig{
+			Enabled:       true,
+			Issuer:        idpServer.URL,
+			ClientID:      "anyrouter-checkin",
+			RedirectURL:   oidcTestRedirectURL,
+			Scopes:        []string{"openid", "profile", "groups"},
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+			AdminGroups:   []string{"anyrouter-admins"},
+			AutoProvision: true,
+			LocalLogin:    true,
+		}
+	})
+}
+
+// startOIDCLogin 以 loginHint 发起单点登录，经模拟身份提供方自动授权，
+// 返回回调地址（含 code 与 state）以及写入浏览器的 state Cookie。
+func (s *testServer) startOIDCLogin(loginHint string) (string, *http.Cookie) {
+	s.t.Helper()
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login?login_hint="+loginHint, nil))
+	if w.Code != http.StatusFound {
+		s.t.Fatalf("发起登录应跳转，实际 %d: %s", w.Code, w.Body.String())
+	}
+	var stateCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "anyrouter_oidc_state" {
+			stateCookie = cookie
+		}
+	}
+	if stateCookie == nil {
+		s.t.Fatal("发起登录时未写入 state Cookie")
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(w.Header().Get("Location"))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusFound || callback == "" {
+		s.t.Fatalf("身份提供方未跳转回回调地址: %d", resp.StatusCode)
+	}
+	return callback, stateCookie
+}
+
+// finishOIDCLogin 以可选的 Cookie 请求回调地址，返回跳转到前端时 URL 片段中的参数。
+func (s *testServer) finishOIDCLogin(callback string, cookie *http.Cookie) url.Values {
+	s.t.Helper()
+	req := httptest.NewRequest(http.MethodGet, callback, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	location, err := url.Parse(w.Header().Get("Location"))
+	if w.Code != http.StatusFound || err != nil {
+		s.t.Fatalf("回调应跳转到前端，实际 %d: %s", w.Code, w.Body.String())
+	}
+	values, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return values
+}
+
+func TestOIDCStateCookie(t *testing.T) {
+	s := newOIDCTestServer(t)
+	callback, cookie := s.startOIDCLogin("victim")
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.Path != "/api/auth/oidc" || cookie.MaxAge <= 0 {
+		t.Fatalf("state Cookie 属性不正确: %+v", cookie)
+	}
+
+	result := s.finishOIDCLogin(callback, cookie)
+	if result.Get("token") == "" || result.Get("error") != "" {
+		t.Fatalf("同一浏览器完成登录应签发令牌: %v", result)
+	}
+}
+
+// 攻击者发起登录后把回调链接发给受害者，受害者的浏览器没有对应的 state Cookie，不应以攻击者身份登录。
+func TestOIDCLoginCSRF(t *testing.T) {
+	s := newOIDCTestServer(t)
+	attackerCallback, _ := s.startOIDCLogin("attacker")
+	_, victimCookie := s.startOIDCLogin("victim")
+
+	for name, cookie := range map[string]*http.Cookie{"no cookie": nil, "victim's own cookie": victimCookie} {
+		result := s.finishOIDCLogin(attackerCallback, cookie)
+		if result.Get("token") != "" || result.Get("error") != service.ErrOIDCState.Error() {
+			t.Fatalf("%s: 回调应被拒绝: %v", name, result)
+		}
+	}
+}
+
+// state Cookie 中的参数经过加密认证，篡改或过期后回调均被拒绝。
+func TestOIDCStateCookieTamperedOrExpired(t *testing.T) {
+	s := newOIDCTestServer(t)
+
+	callback, cookie := s.startOIDCLogin("victim")
+	tampered := *cookie
+	value, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mid := len(value) / 2
+	flipped := byte('A')
+	if value[mid] == 'A' {
+		flipped = 'B'
+	}
+	tampered.Value = url.QueryEscape(value[:mid] + string(flipped) + value[mid+1:])
+	if result := s.finishOIDCLogin(callback, &tampered); result.Get("error") != service.ErrOIDCState.Error() {
+		t.Fatalf("篡改的 Cookie 应被拒绝: %v", result)
+	}
+
+	callback, cookie = s.startOIDCLogin("victim")
+	carbon.SetTestNow(carbon.Now().AddSeconds(service.OIDCStateTTLSeconds + 1))
+	t.Cleanup(carbon.ClearTestNow)
+	if result := s.finishOIDCLogin(callback, cookie); result.Get("error") != service.ErrOIDCState.Error() {
+		t.Fatalf("过期的 Cookie 应被拒绝: %v", result)
+	}
+}
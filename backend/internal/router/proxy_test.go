@@ -0,0 +1,253 @@
+package router
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream/fake"
+)
+
+// streamStub 为模拟流式输出的上游：/api/stream 以查询参数 type 指定的类型（默认 SSE）逐条发送事件，
+// 每条事件发送前等待 release，非 SSE 响应声明 Content-Length；
+// /api/echo 读取请求体后返回 200。两者都会把收到的请求头写入 headers。
+type streamStub struct {
+	release chan struct{}
+	headers chan http.Header
+}
+
+func newStreamStub(t *testing.T) (*streamStub, *httptest.Server) {
+	stub := &streamStub{release: make(chan struct{}), headers: make(chan http.Header, 8)}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (s *streamStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		// WAF Cookie 探测请求，返回普通页面表示无需挑战。
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html></html>")
+	case "/api/stream":
+		s.headers <- r.Header.Clone()
+		contentType := r.URL.Query().Get("type")
+		if contentType == "" {
+			contentType = "text/event-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		if contentType != "text/event-stream" {
+			w.Header().Set("Content-Length", strconv.Itoa(3*len("data: chunk-N\n\n")))
+		}
+		flusher := w.(http.Flusher)
+		flusher.Flush()
+		for i := 1; i <= 3; i++ {
+			select {
+			case <-s.release:
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprintf(w, "data: chunk-%d\n\n", i)
+			flusher.Flush()
+		}
+	case "/api/echo":
+		s.headers <- r.Header.Clone()
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"success":true}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newProxyAccount 直接写入一个启用的账号，user_id 已知，无需解析 session。
+func newProxyAccount(t *testing.T) uint {
+	t.Helper()
+	account := model.Account{OwnerID: 1, Session: "proxy-test-session", UserID: 42, Username: "proxy", Status: 1}
+	if err := repository.CreateAccount(&account); err != nil {
+		t.Fatal(err)
+	}
+	return account.ID
+}
+
+// proxy 经真实 HTTP 服务发送代理请求并返回响应体，ReverseProxy 依赖 ResponseRecorder 不支持的 CloseNotify。
+func (s *testServer) proxy(method, path, body string, header http.Header) string {
+	s.t.Helper()
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return string(data)
+}
+
+// ReverseProxy 对 text/event-stream 与未声明长度的响应总是立即刷新，声明了长度但分段写出的响应依赖 proxyFlushInterval。
+func TestProxyStreamsIncrementally(t *testing.T) {
+	for _, contentType := range []string{"text/event-stream", "application/x-ndjson"} {
+		t.Run(contentType, func(t *testing.T) {
+			stub, upstreamServer := newStreamStub(t)
+			s := newTestServer(t, upstreamServer.URL, nil)
+			accountID := newProxyAccount(t)
+			server := httptest.NewServer(s.engine)
+			defer server.Close()
+
+			target := fmt.Sprintf("%s/anyrouter/%d/api/stream?type=%s", server.URL, accountID, contentType)
+			req, _ := http.NewRequest(http.MethodGet, target, nil)
+			req.Header.Set("Authorization", "Bearer "+s.token)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if ct := resp.Header.Get("Content-Type"); ct != contentType {
+				body, _ := io.ReadAll(resp.Body)
+				t.Fatalf("Content-Type = %q: %s", ct, body)
+			}
+
+			// 上游在调用方读到上一条事件之前不会发送下一条，代理若缓冲响应会在此超时。
+			lines := make(chan string)
+			go func() {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if line := scanner.Text(); line != "" {
+						lines <- line
+					}
+				}
+				close(lines)
+			}()
+			for i := 1; i <= 3; i++ {
+				stub.release <- struct{}{}
+				select {
+				case line := <-lines:
+					if want := fmt.Sprintf("data: chunk-%d", i); line != want {
+						t.Fatalf("got %q, want %q", line, want)
+					}
+				case <-time.After(10 * proxyFlushWait):
+					t.Fatalf("第 %d 条事件未及时转发", i)
+				}
+			}
+		})
+	}
+}
+
+// proxyFlushWait 为等待单条事件的时间单位，大于代理的刷新间隔。
+const proxyFlushWait = 200 * time.Millisecond
+
+func TestProxyHeaderAllowList(t *testing.T) {
+	for _, forward := range []bool{false, true} {
+		t.Run(fmt.Sprintf("forward_client_ip=%v", forward), func(t *testing.T) {
+			stub, upstreamServer := newStreamStub(t)
+			s := newTestServer(t, upstreamServer.URL, func(cfg *config.Config) {
+				cfg.Proxy.ForwardClientIP = forward
+			})
+			accountID := newProxyAccount(t)
+
+			resp := s.proxy(http.MethodPost, fmt.Sprintf("/anyrouter/%d/api/echo", accountID), `{}`, http.Header{
+				"Content-Type":    {"application/json"},
+				"X-Custom":        {"leak"},
+				"X-Forwarded-For": {"198.51.100.1"},
+			})
+			if resp != `{"success":true}` {
+				t.Fatalf("unexpected response: %s", resp)
+			}
+
+			got := <-stub.headers
+			if v := got.Get("Authorization"); v != "" {
+				t.Fatalf("Authorization 不应透传: %q", v)
+			}
+			if v := got.Get("X-Custom"); v != "" {
+				t.Fatalf("未列入白名单的请求头不应透传: %q", v)
+			}
+			if got.Get("Content-Type") != "application/json" {
+				t.Fatalf("Content-Type 应透传: %q", got.Get("Content-Type"))
+			}
+			if !strings.Contains(got.Get("Cookie"), "session=proxy-test-session") || got.Get("New-Api-User") != "42" {
+				t.Fatalf("缺少账号登录态: cookie=%q new-api-user=%q", got.Get("Cookie"), got.Get("New-Api-User"))
+			}
+
+			xff := got.Get("X-Forwarded-For")
+			if forward {
+				if xff != "127.0.0.1" || got.Get("X-Forwarded-Proto") != "http" || got.Get("X-Forwarded-Host") == "" {
+					t.Fatalf("X-Forwarded-* 不正确: for=%q proto=%q host=%q", xff, got.Get("X-Forwarded-Proto"), got.Get("X-Forwarded-Host"))
+				}
+			} else {
+				for _, key := range []string{"X-Forwarded-For", "X-Forwarded-Host", "X-Forwarded-Proto"} {
+					if v := got.Get(key); v != "" {
+						t.Fatalf("未开启 forward_client_ip 时不应发送 %s: %q", key, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestProxyRejectsOversizedBody(t *testing.T) {
+	_, upstreamServer := newStreamStub(t)
+	s := newTestServer(t, upstreamServer.URL, func(cfg *config.Config) {
+		cfg.Proxy.MaxBodySize = 1024
+	})
+	accountID := newProxyAccount(t)
+
+	body := `{"prompt":"` + strings.Repeat("x", 4096) + `"}`
+	resp := s.proxy(http.MethodPost, fmt.Sprintf("/anyrouter/%d/api/echo", accountID), body, http.Header{"Content-Type": {"application/json"}})
+	if !strings.Contains(resp, `"code":413`) {
+		t.Fatalf("超出 proxy.max_body_size 应返回 413: %s", resp)
+	}
+}
+
+// TestProxyRetriesWafChallenge 上游轮换挑战后，缓存的 WAF Cookie 失效，代理应求解新 Cookie 并重放请求，
+// 调用方收到的是业务响应而非挑战页。
+func TestProxyRetriesWafChallenge(t *testing.T) {
+	upstreamFake := fake.New()
+	upstreamFake.SetChallenge(true)
+	upstreamServer := upstreamFake.Start()
+	defer upstreamServer.Close()
+	userID, session := upstreamFake.AddUser("proxy", 0)
+
+	s := newTestServer(t, upstreamServer.URL, nil)
+	account := model.Account{OwnerID: 1, Session: session, UserID: userID, Username: "proxy", Status: 1}
+	if err := repository.CreateAccount(&account); err != nil {
+		t.Fatal(err)
+	}
+	tokensPath := fmt.Sprintf("/anyrouter/%d/api/token/", account.ID)
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	if resp := s.proxy(http.MethodGet, tokensPath, "", http.Header{}); !strings.Contains(resp, `"success":true`) {
+		t.Fatalf("首次代理请求应通过挑战: %s", resp)
+	}
+
+	upstreamFake.RotateChallenge()
+	if resp := s.proxy(http.MethodPost, tokensPath, `{"name":"after-rotation"}`, jsonHeader); !strings.Contains(resp, `"success":true`) {
+		t.Fatalf("挑战轮换后带请求体的代理请求应刷新 Cookie 并重放: %s", resp)
+	}
+	upstreamFake.RotateChallenge()
+	resp := s.proxy(http.MethodGet, tokensPath, "", http.Header{})
+	if strings.Contains(resp, "arg1") || !strings.Contains(resp, "after-rotation") {
+		t.Fatalf("挑战轮换后代理不应返回挑战页: %s", resp)
+	}
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"anyrouter-checkin/internal/handler"
 	"anyrouter-checkin/internal/middleware"
+	"anyrouter-checkin/internal/model"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -14,38 +15,121 @@ func Setup(r *gin.Engine) {
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	r.Any("/anyrouter/*path", handler.AnyRouterProxy)
+	r.Any("/anyrouter/*path", middleware.Auth(), middleware.RequireRole(model.RoleOperator), middleware.Audit(), handler.AnyRouterProxy)
+	r.Any("/v1/*path", handler.Relay)
 
 	api := r.Group("/api")
 	{
 		api.POST("/auth/login", handler.Login)
+		api.POST("/auth/login/2fa", handler.TwoFactorLogin)
+		api.POST("/auth/refresh", handler.RefreshToken)
+		api.GET("/auth/methods", handler.AuthMethods)
+		api.GET("/auth/oidc/login", handler.OIDCLogin)
+		api.GET("/auth/oidc/callback", handler.OIDCCallback)
 		api.POST("/accounts/verify", handler.VerifyAccount)
 
+		// viewer：只读；登录后的修改类请求均记录审计日志
 		auth := api.Group("")
-		auth.Use(middleware.Auth())
+		auth.Use(middleware.Auth(), middleware.Audit())
 		{
 			auth.GET("/auth/profile", handler.Profile)
-			auth.PUT("/auth/password", handler.ChangePassword)
+			auth.PUT("/auth/notification", handler.UpdateNotification)
 
 			auth.GET("/accounts", handler.ListAccounts)
-			auth.POST("/accounts", handler.CreateAccount)
-			auth.PUT("/accounts/:id", handler.UpdateAccount)
-			auth.PUT("/accounts/:id/status", handler.UpdateAccountStatus)
-			auth.DELETE("/accounts/:id", handler.DeleteAccount)
-			auth.POST("/accounts/:id/checkin", handler.CheckinAccount)
-			auth.POST("/accounts/:id/refresh", handler.RefreshAccount)
+			auth.GET("/accounts/:id/tokens", handler.ListAccountTokens)
+			auth.GET("/accounts/:id/balance-history", handler.ListBalanceHistory)
 
+			auth.GET("/usage", handler.ListUsageRecords)
+			auth.GET("/usage/stats", handler.UsageStats)
+			auth.GET("/usage/cursors", handler.ListUsageCursors)
+
+			auth.GET("/topup", handler.ListTopupRecords)
+			auth.GET("/profiles", handler.ListBrowserProfiles)
 			auth.GET("/cron", handler.ListCronTasks)
-			auth.POST("/cron", handler.CreateCronTask)
-			auth.PUT("/cron/:id", handler.UpdateCronTask)
-			auth.DELETE("/cron/:id", handler.DeleteCronTask)
-			auth.POST("/cron/:id/trigger", handler.TriggerCronTask)
+			auth.GET("/logs", handler.ListLogs)
+		}
 
-			auth.GET("/config/:category", handler.GetConfigs)
-			auth.PUT("/config/:category", handler.UpdateConfigs)
-			auth.POST("/config/telegram/test", handler.TestTelegram)
+		// 仅限登录会话：个人访问令牌不能修改密码、管理令牌、会话或两步验证
+		session := auth.Group("")
+		session.Use(middleware.RequireSession())
+		{
+			session.POST("/auth/logout", handler.Logout)
+			session.GET("/auth/sessions", handler.ListLoginSessions)
+			session.DELETE("/auth/sessions", handler.RevokeOtherLoginSessions)
+			session.DELETE("/auth/sessions/:id", handler.RevokeLoginSession)
+			session.PUT("/auth/password", handler.ChangePassword)
+			session.GET("/auth/tokens", handler.ListPersonalTokens)
+			session.POST("/auth/tokens", handler.CreatePersonalToken)
+			session.DELETE("/auth/tokens/:id", handler.RevokePersonalToken)
+			session.POST("/auth/2fa/setup", handler.SetupTwoFactor)
+			session.POST("/auth/2fa/enable", handler.EnableTwoFactor)
+			session.POST("/auth/2fa/disable", handler.DisableTwoFactor)
+			session.POST("/auth/2fa/recovery-codes", handler.RegenerateRecoveryCodes)
+		}
 
-			auth.GET("/logs", handler.ListLogs)
+		// operator：签到、刷新等日常操作
+		operator := auth.Group("")
+		operator.Use(middleware.RequireRole(model.RoleOperator))
+		{
+			operator.POST("/accounts", handler.CreateAccount)
+			operator.PUT("/accounts/:id", handler.UpdateAccount)
+			operator.PUT("/accounts/:id/status", handler.UpdateAccountStatus)
+			operator.PUT("/accounts/:id/network", handler.UpdateAccountNetwork)
+			operator.POST("/accounts/:id/checkin", handler.CheckinAccount)
+			operator.POST("/accounts/:id/refresh", handler.RefreshAccount)
+			operator.POST("/accounts/:id/tokens", handler.CreateAccountToken)
+			operator.DELETE("/accounts/:id/tokens/:token_id", handler.DeleteAccountToken)
+			operator.POST("/accounts/:id/usage/sync", handler.SyncAccountUsage)
+
+			operator.POST("/topup", handler.RedeemCodes)
+
+			operator.POST("/profiles", handler.CreateBrowserProfile)
+			operator.PUT("/profiles/:id", handler.UpdateBrowserProfile)
+			operator.DELETE("/profiles/:id", handler.DeleteBrowserProfile)
+
+			operator.POST("/cron", handler.CreateCronTask)
+			operator.PUT("/cron/:id", handler.UpdateCronTask)
+			operator.DELETE("/cron/:id", handler.DeleteCronTask)
+			operator.POST("/cron/:id/trigger", handler.TriggerCronTask)
+		}
+
+		// admin：删除账号、中转、系统配置与用户管理
+		admin := auth.Group("")
+		admin.Use(middleware.RequireRole(model.RoleAdmin))
+		{
+			admin.DELETE("/accounts/:id", handler.DeleteAccount)
+			admin.PUT("/accounts/:id/owner", handler.TransferAccount)
+
+			admin.GET("/relay/keys", handler.ListRelayKeys)
+			admin.POST("/relay/keys", handler.CreateRelayKey)
+			admin.PUT("/relay/keys/:id", handler.UpdateRelayKey)
+			admin.DELETE("/relay/keys/:id", handler.DeleteRelayKey)
+			admin.GET("/relay/channels", handler.ListRelayChannels)
+			admin.POST("/relay/channels", handler.CreateRelayChannel)
+			admin.PUT("/relay/channels/:id", handler.UpdateRelayChannel)
+			admin.DELETE("/relay/channels/:id", handler.DeleteRelayChannel)
+			admin.GET("/relay/stats", handler.RelayStats)
+
+			admin.GET("/config/history", handler.ListConfigVersions)
+			admin.POST("/config/rollback", handler.RollbackConfig)
+			admin.POST("/config/export", handler.ExportConfigs)
+			admin.POST("/config/import", handler.ImportConfigs)
+			admin.GET("/config/:category", handler.GetConfigs)
+			admin.PUT("/config/:category", handler.UpdateConfigs)
+			admin.POST("/config/telegram/test", handler.TestTelegram)
+
+			admin.GET("/users", handler.ListUsers)
+			admin.POST("/users", handler.CreateUser)
+			admin.PUT("/users/:id", handler.UpdateUser)
+			admin.PUT("/users/:id/password", handler.ResetUserPassword)
+			admin.DELETE("/users/:id/2fa", handler.ResetUserTwoFactor)
+			admin.GET("/login-attempts", handler.ListLoginAttempts)
+			admin.GET("/audit", handler.ListAuditEvents)
+
+			admin.GET("/teams", handler.ListTeams)
+			admin.POST("/teams", handler.CreateTeam)
+			admin.PUT("/teams/:id", handler.UpdateTeam)
+			admin.DELETE("/teams/:id", handler.DeleteTeam)
 		}
 	}
 }
@@ -0,0 +1,144 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testAdminPassword = "router-test-password"
+
+// testServer 为使用临时 SQLite 数据库的完整路由，token 为管理员的访问令牌。
+type testServer struct {
+	t      *testing.T
+	engine *gin.Engine
+	token  string
+}
+
+// newTestServer 以 upstreamURL 为上游初始化配置、数据库与路由，并登录管理员。
+// configure 可在初始化前修改配置。
+func newTestServer(t *testing.T, upstreamURL string, configure func(*config.Config)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Server:   config.ServerConfig{Port: 8080, Mode: "test"},
+		Database: config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "app.db")},
+		JWT:      config.JWTConfig{Secret: "router-test-secret", Expire: time.Hour, RefreshExpire: 24 * time.Hour},
+		AES:      config.AESConfig{Key: "0123456789abcdef0123456789abcdef"},
+		Admin:    config.AdminConfig{Username: "admin", Password: testAdminPassword},
+		Proxy:    config.ProxyConfig{DialTimeout: 5 * time.Second, ResponseHeaderTimeout: 5 * time.Second, MaxBodySize: 10 << 20},
+		Upstream: config.UpstreamConfig{BaseURL: upstreamURL},
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	config.Set(cfg)
+
+	if err := repository.Init(cfg.Database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := repository.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := service.InitDefaultConfigs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.InitAdminUser(); err != nil {
+		t.Fatal(err)
+	}
+	service.InitUpstream(upstreamURL)
+
+	// 登录限制为进程内状态，每个测试使用新的限制器，阈值与默认策略相同。
+	service.SetLoginLimiters(
+		service.NewMemoryLoginLimiter(service.LoginLimitPolicy{MaxFailures: 5, BaseLockout: time.Minute, MaxLockout: time.Hour, ResetAfter: time.Hour}),
+		service.NewMemoryLoginLimiter(service.LoginLimitPolicy{MaxFailures: 20, BaseLockout: time.Minute, MaxLockout: time.Hour, ResetAfter: time.Hour}),
+	)
+
+	engine := gin.New()
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	Setup(engine)
+	login, err := service.Login(cfg.Admin.Username, testAdminPassword, service.LoginClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testServer{t: t, engine: engine, token: login.Token}
+}
+
+// login 以 remoteAddr 为来源地址调用登录接口，forwardedFor 非空时附带 X-Forwarded-For，返回业务码。
+func (s *testServer) login(remoteAddr, forwardedFor, username, password string) int {
+	s.t.Helper()
+	body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+	req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Content-Type", "application/json")
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	var resp struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		s.t.Fatalf("登录响应不是 JSON: %s", w.Body.String())
+	}
+	return resp.Code
+}
+
+// do 以管理员身份发送请求，body 非 nil 时编码为 JSON。
+func (s *testServer) do(method, path string, body any) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w
+}
+
+// call 发送请求并把响应的 data 解码到 out，业务码不为 0 时测试失败。
+func (s *testServer) call(method, path string, body, out any) {
+	s.t.Helper()
+	w := s.do(method, path, body)
+	var resp struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		s.t.Fatalf("%s %s: 响应不是 JSON: %s", method, path, w.Body.String())
+	}
+	if resp.Code != 0 {
+		s.t.Fatalf("%s %s: code %d: %s", method, path, resp.Code, resp.Message)
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			s.t.Fatalf("%s %s: 解码 data 失败: %v", method, path, err)
+		}
+	}
+}
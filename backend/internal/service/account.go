@@ -6,24 +6,26 @@ import (
 
 	"anyrouter-checkin/internal/model"
 	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
 
 	"gorm.io/gorm"
 )
 
-var ErrInvalidSession = errors.New("session 无效")
+var ErrInvalidSession = upstream.ErrUnauthorized
 var ErrAccountDisabled = errors.New("账号已禁用")
 
-func ListAccounts() ([]model.Account, error) {
-	return repository.ListAccounts()
+func ListAccounts(scope Scope) ([]model.Account, error) {
+	return repository.ListAccountsInScope(scope)
 }
 
-func CreateAccount(session string) (model.Account, error) {
+func CreateAccount(ownerID uint, session string) (model.Account, error) {
 	info, err := ParseSession(session)
 	if err != nil {
 		return model.Account{}, fmt.Errorf("%w: %v", ErrInvalidSession, err)
 	}
 
 	account := model.Account{
+		OwnerID:  ownerID,
 		Session:  session,
 		UserID:   info.UserID,
 		Username: info.Username,
@@ -51,7 +53,7 @@ func UpdateAccount(id uint, session string) (model.Account, error) {
 	if err != nil {
 		return model.Account{}, fmt.Errorf("%w: %v", ErrInvalidSession, err)
 	}
-	selfInfo, err := fetchAccountSelf(session, info.UserID)
+	selfInfo, err := fetchAccountSelf(account, session, info.UserID)
 	if err != nil {
 		return model.Account{}, fmt.Errorf("获取账号信息失败: %w", err)
 	}
@@ -81,7 +83,7 @@ func RefreshAccount(id uint) (model.Account, error) {
 		return model.Account{}, fmt.Errorf("%w: %v", ErrInvalidSession, err)
 	}
 
-	info, err := fetchAccountSelf(account.Session, sessionInfo.UserID)
+	info, err := fetchAccountSelf(account, account.Session, sessionInfo.UserID)
 	if err != nil {
 		return model.Account{}, fmt.Errorf("获取账号信息失败: %w", err)
 	}
@@ -116,9 +118,37 @@ func DeleteAccount(id uint) error {
 	if err := removeAccountFromCronTasks(id); err != nil {
 		return err
 	}
+	if err := repository.DeleteUsageByAccount(id); err != nil {
+		return err
+	}
+	if err := repository.DeleteTopupByAccount(id); err != nil {
+		return err
+	}
+	if err := repository.DeleteRelayChannelsByAccount(id); err != nil {
+		return err
+	}
 	return repository.DeleteAccount(id)
 }
 
 func IsRecordNotFound(err error) bool {
 	return errors.Is(err, gorm.ErrRecordNotFound)
 }
+
+// TransferAccount 将账号转交给其他用户。
+func TransferAccount(id, ownerID uint) (model.Account, error) {
+	if _, err := repository.GetUserByID(ownerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Account{}, ErrUserNotFound
+		}
+		return model.Account{}, err
+	}
+	account, err := repository.GetAccountByID(id)
+	if err != nil {
+		return model.Account{}, err
+	}
+	account.OwnerID = ownerID
+	if err := repository.SaveAccount(account); err != nil {
+		return model.Account{}, err
+	}
+	return *account, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+)
+
+const maxAuditPageSize = 200
+
+var ErrInvalidAuditQuery = errors.New("审计查询参数无效")
+
+// AuditQuery 为审计记录的查询条件，日期格式为 YYYY-MM-DD，结束日期包含当天。
+type AuditQuery struct {
+	ActorName  string
+	Action     string
+	TargetType string
+	TargetID   string
+	StartDate  string
+	EndDate    string
+}
+
+type AuditEventPage struct {
+	Items []model.AuditEvent `json:"items"`
+	Total int64              `json:"total"`
+}
+
+func ListAuditEvents(query AuditQuery, page, size int) (AuditEventPage, error) {
+	filter := repository.AuditFilter{
+		ActorName:  strings.TrimSpace(query.ActorName),
+		Action:     strings.TrimSpace(query.Action),
+		TargetType: strings.TrimSpace(query.TargetType),
+		TargetID:   strings.TrimSpace(query.TargetID),
+	}
+	if query.StartDate != "" {
+		if carbon.ParseByLayout(query.StartDate, carbon.DateLayout).HasError() {
+			return AuditEventPage{}, fmt.Errorf("%w: 日期格式应为 YYYY-MM-DD", ErrInvalidAuditQuery)
+		}
+		filter.Since = query.StartDate
+	}
+	if query.EndDate != "" {
+		end := carbon.ParseByLayout(query.EndDate, carbon.DateLayout)
+		if end.HasError() {
+			return AuditEventPage{}, fmt.Errorf("%w: 日期格式应为 YYYY-MM-DD", ErrInvalidAuditQuery)
+		}
+		filter.Before = end.AddDay().ToDateString()
+	}
+	if filter.Since != "" && filter.Before != "" && filter.Since >= filter.Before {
+		return AuditEventPage{}, fmt.Errorf("%w: 开始日期不能晚于结束日期", ErrInvalidAuditQuery)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > maxAuditPageSize {
+		size = 50
+	}
+
+	events, total, err := repository.ListAuditEvents(filter, page, size)
+	if err != nil {
+		return AuditEventPage{}, err
+	}
+	return AuditEventPage{Items: events, Total: total}, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+)
+
+func TestListAuditEventsDateRange(t *testing.T) {
+	setupTestDB(t)
+	for _, createdAt := range []string{"2026-03-01 23:59:59", "2026-03-02 00:00:00", "2026-03-03 12:00:00"} {
+		event := model.AuditEvent{ActorName: "admin", Action: "PUT /api/config", CreatedAt: carbon.DateTime{Carbon: carbon.Parse(createdAt)}}
+		if err := repository.CreateAuditEvent(&event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		wantTotal int64
+		wantErr   bool
+	}{
+		{name: "不限日期", wantTotal: 3},
+		{name: "结束日期包含当天", end: "2026-03-02", wantTotal: 2},
+		{name: "单日", start: "2026-03-02", end: "2026-03-02", wantTotal: 1},
+		{name: "仅开始日期", start: "2026-03-02", wantTotal: 2},
+		{name: "开始日期格式错误", start: "2026-3-2", wantErr: true},
+		{name: "结束日期格式错误", end: "2026/03/02", wantErr: true},
+		{name: "日期不存在", end: "2026-02-30", wantErr: true},
+		{name: "开始晚于结束", start: "2026-03-03", end: "2026-03-02", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := ListAuditEvents(AuditQuery{StartDate: tt.start, EndDate: tt.end}, 1, 50)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAuditQuery) {
+					t.Fatalf("err = %v, want ErrInvalidAuditQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != tt.wantTotal {
+				t.Fatalf("total = %d, want %d", page.Total, tt.wantTotal)
+			}
+		})
+	}
+}
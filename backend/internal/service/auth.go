@@ -2,42 +2,58 @@ package service
 
 import (
 	"errors"
+	"strings"
 
 	"anyrouter-checkin/internal/config"
-	"anyrouter-checkin/internal/middleware"
 	"anyrouter-checkin/internal/model"
 	"anyrouter-checkin/internal/repository"
 
-	"github.com/dromara/carbon/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-func Login(username, password string) (string, error) {
+// Login 校验用户名与密码，开启两步验证的用户返回挑战，需调用 CompleteTwoFactorLogin 完成登录。
+// 用户不存在与密码错误返回相同的错误，失败次数过多时按 IP 与用户名锁定。
+func Login(username, password string, client LoginClient) (LoginResult, error) {
+	if !localLoginEnabled() {
+		return LoginResult{}, ErrLocalLoginDisabled
+	}
+	username = strings.TrimSpace(username)
+	ip := client.IP
+	if err := checkLoginLocked(username, ip); err != nil {
+		return LoginResult{}, err
+	}
+
 	user, err := repository.GetUserByUsername(username)
 	if err != nil {
-		return "", errors.New("用户不存在")
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return LoginResult{}, err
+		}
+		// 对不存在的用户同样执行一次 bcrypt 比较，避免通过响应时间枚举用户名。
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return LoginResult{}, failLogin(username, ip, LoginFailureUnknownUser)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("密码错误")
+		return LoginResult{}, failLogin(username, ip, LoginFailureWrongPassword)
+	}
+	if user.Status != 1 {
+		return LoginResult{}, ErrUserDisabled
 	}
 
-	return generateToken(user.ID, user.Username)
-}
-
-func generateToken(userID uint, username string) (string, error) {
-	now := carbon.Now().StdTime()
-	claims := middleware.Claims{
-		UserID:   userID,
-		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(config.C.JWT.Expire)),
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
+	if user.TOTPEnabled {
+		challenge, err := newLoginChallenge(user.ID, ip)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		return LoginResult{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+	result, err := startLoginSession(user, client)
+	if err != nil {
+		return LoginResult{}, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.C.JWT.Secret))
+	resetLoginFailures(username)
+	return result, nil
 }
 
 func InitAdminUser() error {
@@ -46,35 +62,49 @@ func InitAdminUser() error {
 		return err
 	}
 	if count > 0 {
-		return nil
+		// 升级前只有单个管理员，未设置角色的用户视为管理员，已有账号与任务归属到该管理员。
+		if err := repository.BackfillUserRoles(model.RoleAdmin); err != nil {
+			return err
+		}
+		admin, err := repository.GetFirstAdmin()
+		if err != nil {
+			return err
+		}
+		return repository.BackfillOwners(admin.ID)
 	}
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(config.C.Admin.Password), bcrypt.DefaultCost)
+	hashed, err := bcrypt.GenerateFromPassword([]byte(config.Get().Admin.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
 	return repository.CreateUser(&model.User{
-		Username: config.C.Admin.Username,
+		Username: config.Get().Admin.Username,
 		Password: string(hashed),
+		Role:     model.RoleAdmin,
+		Status:   1,
 	})
 }
 
-func ChangePassword(userID uint, oldPassword, newPassword string) error {
+// ChangePassword 修改密码后吊销该用户的全部会话，并为当前客户端签发新的令牌。
+func ChangePassword(userID uint, oldPassword, newPassword string, client LoginClient) (LoginResult, error) {
 	user, err := repository.GetUserByID(userID)
 	if err != nil {
-		return errors.New("用户不存在")
+		return LoginResult{}, errors.New("用户不存在")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
-		return errors.New("原密码错误")
+		return LoginResult{}, errors.New("原密码错误")
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return errors.New("密码加密失败")
+		return LoginResult{}, errors.New("密码加密失败")
 	}
 
-	user.Password = string(hashed)
-	return repository.SaveUser(user)
+	if err := repository.UpdateUserPassword(user.ID, string(hashed)); err != nil {
+		return LoginResult{}, err
+	}
+	user.TokenVersion++
+	return startLoginSession(user, client)
 }
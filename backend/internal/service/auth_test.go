@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+func TestUpdatePasswordRevokesTokens(t *testing.T) {
+	const newPassword = "service-test-new-password"
+	tests := []struct {
+		name string
+		// update 修改密码，返回的 LoginResult 非空时为修改后签发的新会话。
+		update func(user *model.User) (LoginResult, error)
+	}{
+		{"ChangePassword", func(user *model.User) (LoginResult, error) {
+			return ChangePassword(user.ID, config.Get().Admin.Password, newPassword, LoginClient{})
+		}},
+		{"ResetUserPassword", func(user *model.User) (LoginResult, error) {
+			return LoginResult{}, ResetUserPassword(user.ID, newPassword)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := setupTestAdmin(t)
+			old, err := Login(user.Username, config.Get().Admin.Password, LoginClient{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tt.update(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := RefreshLoginSession(old.RefreshToken, LoginClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Fatalf("修改密码后旧的刷新令牌应失效: %v", err)
+			}
+			if result.RefreshToken != "" {
+				if _, err := RefreshLoginSession(result.RefreshToken, LoginClient{}); err != nil {
+					t.Fatalf("修改密码后签发的新会话应可用: %v", err)
+				}
+			}
+			if _, err := Login(user.Username, newPassword, LoginClient{}); err != nil {
+				t.Fatalf("应可使用新密码登录: %v", err)
+			}
+		})
+
+		// 吊销会话失败时整个事务回滚：密码与令牌版本不变，旧会话仍然可用。
+		t.Run(tt.name+"/回滚", func(t *testing.T) {
+			user := setupTestAdmin(t)
+			old, err := Login(user.Username, config.Get().Admin.Password, LoginClient{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			errRevoke := errors.New("吊销会话失败")
+			if err := repository.DB.Callback().Delete().Before("gorm:delete").Register("test:fail_revoke", func(tx *gorm.DB) {
+				if tx.Statement.Table == "login_sessions" {
+					_ = tx.AddError(errRevoke)
+				}
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tt.update(user); !errors.Is(err, errRevoke) {
+				t.Fatalf("err = %v, want %v", err, errRevoke)
+			}
+			if err := repository.DB.Callback().Delete().Remove("test:fail_revoke"); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repository.GetUserByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Password != user.Password || got.TokenVersion != user.TokenVersion {
+				t.Fatal("吊销失败时不应修改密码或令牌版本")
+			}
+			if _, err := RefreshLoginSession(old.RefreshToken, LoginClient{}); err != nil {
+				t.Fatalf("吊销失败时旧会话应保持不变: %v", err)
+			}
+		})
+	}
+}
@@ -2,20 +2,18 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/gob"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/http/cookiejar"
 	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
-	"time" // 仅用于 time.Duration 类型
 
 	"anyrouter-checkin/internal/model"
 	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
 
 	"github.com/dromara/carbon/v2"
 	"go.uber.org/zap"
@@ -29,7 +27,15 @@ type SessionInfo struct {
 	Group    string `json:"group"`
 }
 
+// maxSessionLength 限制待解析 Session 的长度，避免超大输入占用过多内存。
+const maxSessionLength = 8192
+
+var ErrSessionTooLong = errors.New("session 过长")
+
 func ParseSession(sessionCookie string) (*SessionInfo, error) {
+	if len(sessionCookie) > maxSessionLength {
+		return nil, ErrSessionTooLong
+	}
 	sessionValue := extractSessionValue(sessionCookie)
 	decoded, err := decodeSessionValue(sessionValue)
 	if err != nil {
@@ -199,17 +205,21 @@ func parseSessionLegacy(gobData []byte) (*SessionInfo, error) {
 			i += 3
 			if i+4 < len(data) && data[i] == 0x04 {
 				encType := data[i+1]
-				var val int
+				var raw uint
 				switch encType {
 				case 0x04:
-					val = int(data[i+4])<<8 | int(data[i+5])
+					if i+5 >= len(data) {
+						i = len(data)
+						continue
+					}
+					raw = uint(data[i+4])<<8 | uint(data[i+5])
 					i += 6
 				case 0x02:
-					val = int(data[i+3])
+					raw = uint(data[i+3])
 					i += 4
 				}
 				if lastKey != "" {
-					kvPairs[lastKey] = val
+					kvPairs[lastKey] = gobSignedInt(raw)
 					lastKey = ""
 				}
 				continue
@@ -240,6 +250,14 @@ func parseSessionLegacy(gobData []byte) (*SessionInfo, error) {
 	return info, nil
 }
 
+// gobSignedInt 还原 gob 有符号整数的编码：最低位为符号位，其余位为绝对值。
+func gobSignedInt(raw uint) int {
+	if raw&1 != 0 {
+		return ^int(raw >> 1)
+	}
+	return int(raw >> 1)
+}
+
 func toString(value interface{}) string {
 	switch v := value.(type) {
 	case string:
@@ -281,118 +299,16 @@ func toInt(value interface{}) (int, bool) {
 	return 0, false
 }
 
-func generateAcwScV2(arg1 string) (string, error) {
-	m := []int{0xf, 0x23, 0x1d, 0x18, 0x21, 0x10, 0x1, 0x26, 0xa, 0x9,
-		0x13, 0x1f, 0x28, 0x1b, 0x16, 0x17, 0x19, 0xd, 0x6, 0xb,
-		0x27, 0x12, 0x14, 0x8, 0xe, 0x15, 0x20, 0x1a, 0x2, 0x1e,
-		0x7, 0x4, 0x11, 0x5, 0x3, 0x1c, 0x22, 0x25, 0xc, 0x24}
-	p := "3000176000856006061501533003690027800375"
-
-	q := make([]byte, len(m))
-	for x := 0; x < len(arg1); x++ {
-		for z := 0; z < len(m); z++ {
-			if m[z] == x+1 {
-				q[z] = arg1[x]
-			}
-		}
-	}
-	u := string(q)
-
-	v := ""
-	minLen := len(u)
-	if len(p) < minLen {
-		minLen = len(p)
-	}
-	for x := 0; x < minLen; x += 2 {
-		a, err := strconv.ParseInt(u[x:x+2], 16, 64)
-		if err != nil {
-			return "", err
-		}
-		b, err := strconv.ParseInt(p[x:x+2], 16, 64)
-		if err != nil {
-			return "", err
-		}
-		v += fmt.Sprintf("%02x", a^b)
-	}
-
-	return v, nil
-}
-
-func Checkin(sessionCookie string) (string, error) {
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return "", fmt.Errorf("初始化 Cookie 失败: %v", err)
-	}
-	client := &http.Client{Jar: jar, Timeout: 30 * time.Second}
-
-	baseURL := "https://anyrouter.top"
-	sessionValue := extractSessionValue(sessionCookie)
+func Checkin(account *model.Account) (*upstream.SignInResult, error) {
+	sessionValue := extractSessionValue(account.Session)
 	if sessionValue == "" {
-		return "", fmt.Errorf("session 为空")
-	}
-	headers := map[string]string{
-		"accept":          "application/json, text/plain, */*",
-		"accept-language": "zh-CN,zh;q=0.9",
-		"cache-control":   "no-store",
-		"origin":          baseURL,
-		"referer":         baseURL + "/console/personal",
-		"user-agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36",
+		return nil, fmt.Errorf("session 为空")
 	}
-
-	req, err := http.NewRequest("GET", baseURL+"/", nil)
+	result, err := upstreamAPI.SignIn(context.Background(), accountCredential(account, account.Session, 0))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %v", err)
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
+		return nil, fmt.Errorf("签到失败: %w", err)
 	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %v", err)
-	}
-	body, err := io.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %v", err)
-	}
-
-	re := regexp.MustCompile(`(?i)arg1='([a-f0-9]+)'`)
-	matches := re.FindStringSubmatch(string(body))
-	var acwScV2 string
-	if len(matches) > 1 {
-		acwScV2, err = generateAcwScV2(matches[1])
-		if err != nil {
-			return "", fmt.Errorf("生成 Cookie 失败: %v", err)
-		}
-	}
-
-	u, err := url.Parse(baseURL)
-	if err != nil {
-		return "", fmt.Errorf("解析地址失败: %v", err)
-	}
-	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: sessionValue}})
-	if acwScV2 != "" {
-		jar.SetCookies(u, []*http.Cookie{{Name: "acw_sc__v2", Value: acwScV2}})
-	}
-
-	req, err = http.NewRequest("POST", baseURL+"/api/user/sign_in", nil)
-	if err != nil {
-		return "", fmt.Errorf("创建签到请求失败: %v", err)
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	resp, err = client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("签到请求失败: %v", err)
-	}
-	body, err = io.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return "", fmt.Errorf("读取签到响应失败: %v", err)
-	}
-
-	return string(body), nil
+	return result, nil
 }
 
 func CheckinAccount(accountID uint) (bool, string) {
@@ -404,11 +320,16 @@ func CheckinAccount(accountID uint) (bool, string) {
 		return false, ErrAccountDisabled.Error()
 	}
 
-	result, err := Checkin(account.Session)
+	signIn, err := Checkin(account)
 	if err != nil {
+		var challengeErr *ChallengeError
+		if errors.As(err, &challengeErr) {
+			reportCheckinFailure(account, err.Error())
+		}
 		return false, err.Error()
 	}
 
+	result := signIn.Raw
 	// 禁止在签到时更新余额，余额刷新应由独立接口完成。
 	now := carbon.DateTime{Carbon: carbon.Now()}
 	account.LastCheckin = &now
@@ -417,7 +338,7 @@ func CheckinAccount(accountID uint) (bool, string) {
 		return false, "保存签到结果失败: " + err.Error()
 	}
 
-	success := strings.Contains(result, "success") || strings.Contains(result, "已签到")
+	success := signIn.Success || signIn.AlreadySigned
 	if err := repository.CreateCheckinLog(&model.CheckinLog{
 		AccountID: accountID,
 		Success:   success,
@@ -426,7 +347,19 @@ func CheckinAccount(accountID uint) (bool, string) {
 		return false, "记录签到日志失败: " + err.Error()
 	}
 
-	SendCheckinNotification(strings.TrimSpace(account.Username), success, result)
+	SendCheckinNotification(account.OwnerID, strings.TrimSpace(account.Username), success, result)
 
 	return success, result
 }
+
+// reportCheckinFailure 记录无法自动恢复的签到失败（如 WAF 挑战无法求解），并推送通知。
+func reportCheckinFailure(account *model.Account, message string) {
+	if err := repository.CreateCheckinLog(&model.CheckinLog{
+		AccountID: account.ID,
+		Success:   false,
+		Message:   message,
+	}); err != nil {
+		zap.L().Warn("记录签到日志失败", zap.Uint("account_id", account.ID), zap.Error(err))
+	}
+	SendCheckinNotification(account.OwnerID, strings.TrimSpace(account.Username), false, message)
+}
@@ -12,8 +12,8 @@ type CheckinLogSummary struct {
 	TodayCheckinAccountCount int64              `json:"today_checkin_account_count"`
 }
 
-func GetCheckinLogSummary(limit int) (CheckinLogSummary, error) {
-	logs, err := repository.ListCheckinLogs(limit)
+func GetCheckinLogSummary(scope Scope, limit int) (CheckinLogSummary, error) {
+	logs, err := repository.ListCheckinLogs(scope, limit)
 	if err != nil {
 		return CheckinLogSummary{}, err
 	}
@@ -22,7 +22,7 @@ func GetCheckinLogSummary(limit int) (CheckinLogSummary, error) {
 	start := now.StartOfDay().StdTime()
 	end := now.EndOfDay().StdTime()
 
-	count, err := repository.CountSuccessfulAccounts(start, end)
+	count, err := repository.CountSuccessfulAccounts(scope, start, end)
 	if err != nil {
 		return CheckinLogSummary{}, err
 	}
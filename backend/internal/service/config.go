@@ -1,28 +1,125 @@
 package service
 
-import "anyrouter-checkin/internal/repository"
+import (
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+)
 
+// InitDefaultConfigs 写入尚不存在的配置项默认值并加载配置缓存，已有的值保持不变。
+func InitDefaultConfigs() error {
+	defaults := make([]model.Config, 0, len(configDefinitions))
+	for _, def := range configDefinitions {
+		defaults = append(defaults, model.Config{Key: def.Key, Value: def.Default, Category: def.Category})
+	}
+	if err := repository.InitDefaultConfigs(defaults); err != nil {
+		return err
+	}
+	return LoadConfigCache()
+}
+
+// GetConfigs 返回分类下已声明的配置，敏感配置有值时以掩码代替。
 func GetConfigs(category string) (map[string]string, error) {
+	if !configCategoryExists(category) {
+		return nil, ErrConfigCategoryNotFound
+	}
 	configs, err := repository.ListConfigs(category)
 	if err != nil {
 		return nil, err
 	}
+	stored := make(map[string]string, len(configs))
+	for _, cfg := range configs {
+		stored[cfg.Key] = cfg.Value
+	}
 
 	result := make(map[string]string)
-	for _, cfg := range configs {
-		result[cfg.Key] = cfg.Value
+	for _, def := range configDefinitions {
+		if def.Category != category {
+			continue
+		}
+		value, ok := stored[def.Key]
+		if !ok {
+			value = def.Default
+		}
+		if def.Secret && value != "" {
+			value = ConfigMask
+		}
+		result[def.Key] = value
 	}
 	return result, nil
 }
 
+// GetConfig 返回配置值，未保存时使用声明的默认值；配置缓存未加载时（如命令行工具）直接查询数据库。
 func GetConfig(key string) string {
-	value, err := repository.GetConfigValue(key)
-	if err != nil {
+	value, found, loaded := cachedConfig(key)
+	if !loaded {
+		var err error
+		value, err = repository.GetConfigValue(key)
+		found = err == nil
+	}
+	if !found {
+		if def, ok := configDefinitionByKey[key]; ok {
+			return def.Default
+		}
 		return ""
 	}
 	return value
 }
 
-func SetConfig(key, value, category string) error {
-	return repository.SetConfigValue(key, value, category)
+// ConfigActor 为修改配置的操作人，记录在配置变更历史中。
+type ConfigActor struct {
+	ID   uint
+	Name string
+}
+
+// UpdateConfigs 校验并保存分类下的配置：未声明或不属于该分类的键、格式错误的值
+// 均以 *ConfigValidationError 返回且不保存任何值；敏感配置提交掩码时保持原值。
+func UpdateConfigs(category string, values map[string]string, actor ConfigActor) error {
+	if !configCategoryExists(category) {
+		return ErrConfigCategoryNotFound
+	}
+	configs, err := validateConfigValues(category, values)
+	if err != nil {
+		return err
+	}
+	return saveConfigs(configs, ConfigSourceUpdate, actor)
+}
+
+// validateConfigValues 校验并规范化配置值，category 为空时不限制分类。
+func validateConfigValues(category string, values map[string]string) ([]model.Config, error) {
+	fields := make(map[string]string)
+	configs := make([]model.Config, 0, len(values))
+	for key, value := range values {
+		def, ok := configDefinitionByKey[key]
+		if !ok || (category != "" && def.Category != category) {
+			fields[key] = "未知的配置项"
+			continue
+		}
+		if def.Secret && value == ConfigMask {
+			continue
+		}
+		normalized, err := def.normalize(value)
+		if err != nil {
+			fields[key] = err.Error()
+			continue
+		}
+		configs = append(configs, model.Config{Key: key, Value: normalized, Category: def.Category})
+	}
+	if len(fields) > 0 {
+		return nil, &ConfigValidationError{Fields: fields}
+	}
+	return configs, nil
+}
+
+// saveConfigs 保存配置并更新缓存，值发生变化时通知订阅者。
+func saveConfigs(configs []model.Config, source string, actor ConfigActor) error {
+	changes, err := repository.SetConfigValues(configs, model.ConfigVersion{
+		Source:    source,
+		ActorID:   actor.ID,
+		ActorName: actor.Name,
+	})
+	if err != nil {
+		return err
+	}
+	applyConfigChanges(changes)
+	return nil
 }
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"slices"
+	"sync"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+)
+
+// 系统配置缓存在内存中，通过本服务修改后立即更新并通知订阅者；
+// 多个实例共用数据库时，其他实例的修改需重启后生效。
+var (
+	configCacheMu     sync.RWMutex
+	configCache       map[string]string
+	configSubscribers []func(keys []string)
+)
+
+// LoadConfigCache 从数据库加载全部配置，之后 GetConfig 不再查询数据库。
+func LoadConfigCache() error {
+	configs, err := repository.ListAllConfigs()
+	if err != nil {
+		return err
+	}
+	values := make(map[string]string, len(configs))
+	for _, cfg := range configs {
+		values[cfg.Key] = cfg.Value
+	}
+	configCacheMu.Lock()
+	configCache = values
+	configCacheMu.Unlock()
+	return nil
+}
+
+// OnConfigChange 注册系统配置变更回调，参数为值发生变化的配置项，回调在保存配置的请求中同步执行。
+func OnConfigChange(fn func(keys []string)) {
+	configCacheMu.Lock()
+	defer configCacheMu.Unlock()
+	configSubscribers = append(configSubscribers, fn)
+}
+
+// cachedConfig 返回缓存中的配置值，loaded 为 false 表示缓存尚未加载。
+func cachedConfig(key string) (value string, found, loaded bool) {
+	configCacheMu.RLock()
+	defer configCacheMu.RUnlock()
+	if configCache == nil {
+		return "", false, false
+	}
+	value, found = configCache[key]
+	return value, found, true
+}
+
+func applyConfigChanges(changes []model.ConfigVersion) {
+	if len(changes) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(changes))
+	configCacheMu.Lock()
+	for _, change := range changes {
+		if configCache != nil {
+			configCache[change.Key] = change.NewValue
+		}
+		keys = append(keys, change.Key)
+	}
+	subscribers := slices.Clone(configSubscribers)
+	configCacheMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(keys)
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"anyrouter-checkin/internal/upstream"
+
+	"github.com/shopspring/decimal"
+)
+
+// ConfigMask 为敏感配置读取时返回的掩码，提交掩码表示保持原值不变。
+const ConfigMask = "******"
+
+type ConfigType string
+
+const (
+	ConfigTypeString   ConfigType = "string"
+	ConfigTypeBool     ConfigType = "bool"
+	ConfigTypeDecimal  ConfigType = "decimal"
+	ConfigTypeURL      ConfigType = "url"
+	ConfigTypeEnum     ConfigType = "enum"
+	ConfigTypeTemplate ConfigType = "template"
+)
+
+// ConfigDefinition 声明一个系统配置项的分类、类型、默认值与校验规则。
+type ConfigDefinition struct {
+	Key      string
+	Category string
+	Type     ConfigType
+	Default  string
+	Secret   bool
+	// Options 为 enum 类型的可选值，url 类型为允许的协议。
+	Options  []string
+	Validate func(value string) error
+}
+
+var configDefinitions = []ConfigDefinition{
+	{Key: "telegram.api_base", Category: "telegram", Type: ConfigTypeURL, Default: "https://api.telegram.org", Options: []string{"http", "https"}},
+	{Key: "telegram.bot_token", Category: "telegram", Type: ConfigTypeString, Secret: true, Validate: validateBotToken},
+	{Key: "telegram.chat_id", Category: "telegram", Type: ConfigTypeString, Validate: validateChatID},
+	{Key: "telegram.enabled", Category: "telegram", Type: ConfigTypeBool, Default: "false"},
+	// 代理地址常带有 user:pass@，按敏感配置处理。
+	{Key: "telegram.proxy_url", Category: "telegram", Type: ConfigTypeURL, Secret: true, Options: []string{"http", "https", "socks5"}},
+	{Key: "telegram.template", Category: "telegram", Type: ConfigTypeTemplate, Default: defaultTelegramTemplate},
+	{Key: "waf.acw_sc_v2_table", Category: "waf", Type: ConfigTypeString, Validate: upstream.ValidateAcwScV2Table},
+	{Key: "waf.acw_sc_v2_key", Category: "waf", Type: ConfigTypeString, Validate: upstream.ValidateAcwScV2Key},
+	{Key: "relay.strategy", Category: "relay", Type: ConfigTypeEnum, Default: RelayStrategyRoundRobin, Options: []string{RelayStrategyRoundRobin, RelayStrategyBalance}},
+	{Key: "relay.min_balance", Category: "relay", Type: ConfigTypeDecimal, Default: "0", Validate: validateNonNegative},
+	{Key: "scheduler.timezone", Category: "scheduler", Type: ConfigTypeString, Validate: validateTimezone},
+}
+
+var configDefinitionByKey = func() map[string]*ConfigDefinition {
+	defs := make(map[string]*ConfigDefinition, len(configDefinitions))
+	for i := range configDefinitions {
+		defs[configDefinitions[i].Key] = &configDefinitions[i]
+	}
+	return defs
+}()
+
+var ErrConfigCategoryNotFound = errors.New("配置分类不存在")
+
+// ConfigValidationError 为配置校验失败的字段及原因。
+type ConfigValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ConfigValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, key+": "+e.Fields[key])
+	}
+	return "配置校验失败: " + strings.Join(messages, "；")
+}
+
+func configCategoryExists(category string) bool {
+	for _, def := range configDefinitions {
+		if def.Category == category {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize 校验配置值并返回规范化后的值，模板保留原样，其余类型去除首尾空白。
+func (d *ConfigDefinition) normalize(value string) (string, error) {
+	if d.Type != ConfigTypeTemplate {
+		value = strings.TrimSpace(value)
+	}
+	switch d.Type {
+	case ConfigTypeBool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", errors.New("必须为 true 或 false")
+		}
+		value = strconv.FormatBool(v)
+	case ConfigTypeDecimal:
+		if _, err := decimal.NewFromString(value); err != nil {
+			return "", errors.New("必须为数字")
+		}
+	case ConfigTypeURL:
+		if value != "" {
+			u, err := neturl.Parse(value)
+			if err != nil || u.Host == "" || !slices.Contains(d.Options, u.Scheme) {
+				return "", fmt.Errorf("必须为 %s 地址", strings.Join(d.Options, "/"))
+			}
+		}
+	case ConfigTypeEnum:
+		if !slices.Contains(d.Options, value) {
+			return "", fmt.Errorf("可选值为 %s", strings.Join(d.Options, "、"))
+		}
+	case ConfigTypeTemplate:
+		if _, err := template.New(d.Key).Parse(value); err != nil {
+			return "", fmt.Errorf("模板格式错误: %v", err)
+		}
+	}
+	if d.Validate != nil {
+		if err := d.Validate(value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+func validateBotToken(value string) error {
+	id, secret, ok := strings.Cut(value, ":")
+	if value == "" || (ok && id != "" && secret != "" && isDigits(id)) {
+		return nil
+	}
+	return errors.New("格式应为 <数字ID>:<密钥>")
+}
+
+func validateChatID(value string) error {
+	if value == "" || isDigits(strings.TrimPrefix(value, "-")) || (strings.HasPrefix(value, "@") && len(value) > 1) {
+		return nil
+	}
+	return errors.New("必须为数字 ID 或 @频道名")
+}
+
+func validateNonNegative(value string) error {
+	if decimal.RequireFromString(value).IsNegative() {
+		return errors.New("不能小于 0")
+	}
+	return nil
+}
+
+func validateTimezone(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(value); err != nil {
+		return errors.New("时区无效，应为 IANA 时区名，如 Asia/Shanghai")
+	}
+	return nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, ch := range value {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configExportVersion = 1
+	// configSecretPrefix 标记导出文件中使用口令加密的敏感配置。
+	configSecretPrefix  = "enc:"
+	minExportPassphrase = 8
+)
+
+var (
+	ErrInvalidConfigExport = errors.New("导出参数无效")
+	ErrInvalidConfigImport = errors.New("导入文件无效")
+)
+
+// ConfigExport 为配置导出文件的内容，Configs 以配置项为键。
+// 包含敏感配置时使用口令与 Salt 派生的密钥以 AES-GCM 加密，值以 enc: 开头。
+type ConfigExport struct {
+	Version    int               `json:"version" yaml:"version"`
+	ExportedAt string            `json:"exported_at" yaml:"exported_at"`
+	Salt       string            `json:"salt,omitempty" yaml:"salt,omitempty"`
+	Configs    map[string]string `json:"configs" yaml:"configs"`
+}
+
+// ExportConfigs 导出全部已声明的配置，format 为 yaml 或 json。
+// includeSecrets 为 false 时不导出敏感配置，为 true 时需提供至少 8 位的口令用于加密。
+func ExportConfigs(format string, includeSecrets bool, passphrase string) ([]byte, error) {
+	if format != "yaml" && format != "json" {
+		return nil, fmt.Errorf("%w: 格式应为 yaml 或 json", ErrInvalidConfigExport)
+	}
+	export := ConfigExport{
+		Version:    configExportVersion,
+		ExportedAt: carbon.Now().ToRfc3339String(),
+		Configs:    make(map[string]string, len(configDefinitions)),
+	}
+	var gcm cipher.AEAD
+	if includeSecrets {
+		if utf8.RuneCountInString(passphrase) < minExportPassphrase {
+			return nil, fmt.Errorf("%w: 导出敏感配置需要至少 %d 位的口令", ErrInvalidConfigExport, minExportPassphrase)
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		var err error
+		if gcm, err = passphraseCipher(passphrase, salt); err != nil {
+			return nil, err
+		}
+		export.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+
+	configs, err := repository.ListAllConfigs()
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]string, len(configs))
+	for _, cfg := range configs {
+		stored[cfg.Key] = cfg.Value
+	}
+	for _, def := range configDefinitions {
+		value, ok := stored[def.Key]
+		if !ok {
+			value = def.Default
+		}
+		if def.Secret {
+			if !includeSecrets {
+				continue
+			}
+			if value != "" {
+				sealed, err := sealSecret(gcm, value)
+				if err != nil {
+					return nil, err
+				}
+				value = configSecretPrefix + sealed
+			}
+		}
+		export.Configs[def.Key] = value
+	}
+
+	if format == "json" {
+		return json.MarshalIndent(export, "", "  ")
+	}
+	return yaml.Marshal(export)
+}
+
+// ImportConfigs 导入 ExportConfigs 生成的 YAML 或 JSON 文件，全部配置项校验通过后在同一事务中保存。
+// 加密的敏感配置需提供导出时的口令；校验或解密失败时返回 *ConfigValidationError 且不保存任何值。
+// 返回导入的配置项数量。
+func ImportConfigs(data []byte, passphrase string, actor ConfigActor) (int, error) {
+	var export ConfigExport
+	if err := yaml.Unmarshal(data, &export); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidConfigImport, err)
+	}
+	if export.Version != configExportVersion {
+		return 0, fmt.Errorf("%w: 不支持的版本 %d", ErrInvalidConfigImport, export.Version)
+	}
+	if len(export.Configs) == 0 {
+		return 0, fmt.Errorf("%w: 未包含任何配置", ErrInvalidConfigImport)
+	}
+
+	var gcm cipher.AEAD
+	values := make(map[string]string, len(export.Configs))
+	fields := make(map[string]string)
+	for key, value := range export.Configs {
+		if !strings.HasPrefix(value, configSecretPrefix) {
+			values[key] = value
+			continue
+		}
+		if gcm == nil {
+			salt, err := base64.StdEncoding.DecodeString(export.Salt)
+			if err != nil || len(salt) == 0 || passphrase == "" {
+				fields[key] = "需要导出时的口令才能解密"
+				continue
+			}
+			if gcm, err = passphraseCipher(passphrase, salt); err != nil {
+				return 0, err
+			}
+		}
+		plaintext, err := openSecret(gcm, strings.TrimPrefix(value, configSecretPrefix))
+		if err != nil {
+			fields[key] = "解密失败，请检查口令"
+			continue
+		}
+		values[key] = plaintext
+	}
+	if len(fields) > 0 {
+		return 0, &ConfigValidationError{Fields: fields}
+	}
+
+	configs, err := validateConfigValues("", values)
+	if err != nil {
+		return 0, err
+	}
+	if err := saveConfigs(configs, ConfigSourceImport, actor); err != nil {
+		return 0, err
+	}
+	return len(configs), nil
+}
+
+// passphraseCipher 使用 scrypt 由口令派生 AES-256 密钥，导入方无需与导出方使用相同的 aes.key。
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+)
+
+const (
+	ConfigSourceUpdate   = "update"
+	ConfigSourceRollback = "rollback"
+	ConfigSourceImport   = "import"
+
+	maxConfigVersionPageSize = 200
+)
+
+var ErrInvalidRollbackScope = errors.New("回滚范围应为 key 或 category")
+
+type ConfigVersionPage struct {
+	Items []model.ConfigVersion `json:"items"`
+	Total int64                 `json:"total"`
+}
+
+// ListConfigVersions 返回配置变更历史，敏感配置的新旧值以掩码代替。
+func ListConfigVersions(key, category string, page, size int) (ConfigVersionPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > maxConfigVersionPageSize {
+		size = 50
+	}
+	versions, total, err := repository.ListConfigVersions(repository.ConfigVersionFilter{Key: key, Category: category}, page, size)
+	if err != nil {
+		return ConfigVersionPage{}, err
+	}
+	for i := range versions {
+		maskConfigVersion(&versions[i])
+	}
+	return ConfigVersionPage{Items: versions, Total: total}, nil
+}
+
+// RollbackConfig 撤销指定变更及其之后的修改：scope 为 key 时只恢复该配置项，
+// 为 category 时恢复同分类下全部配置项到该变更之前的值。回滚本身也会记录为一次变更，可再次撤销。
+// 返回被恢复的配置项。
+func RollbackConfig(versionID uint, scope string, actor ConfigActor) ([]string, error) {
+	version, err := repository.GetConfigVersion(versionID)
+	if err != nil {
+		return nil, err
+	}
+	filter := repository.ConfigVersionFilter{}
+	switch scope {
+	case "", "key":
+		filter.Key = version.Key
+	case "category":
+		filter.Category = version.Category
+	default:
+		return nil, ErrInvalidRollbackScope
+	}
+
+	versions, err := repository.ListConfigVersionsFrom(version.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+	var configs []model.Config
+	var keys []string
+	restored := make(map[string]bool)
+	for _, v := range versions {
+		if restored[v.Key] {
+			continue
+		}
+		restored[v.Key] = true
+		if _, ok := configDefinitionByKey[v.Key]; !ok {
+			continue
+		}
+		configs = append(configs, model.Config{Key: v.Key, Value: v.OldValue, Category: v.Category})
+		keys = append(keys, v.Key)
+	}
+	if err := saveConfigs(configs, ConfigSourceRollback, actor); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func maskConfigVersion(version *model.ConfigVersion) {
+	def, ok := configDefinitionByKey[version.Key]
+	if !ok || !def.Secret {
+		return
+	}
+	if version.OldValue != "" {
+		version.OldValue = ConfigMask
+	}
+	if version.NewValue != "" {
+		version.NewValue = ConfigMask
+	}
+}
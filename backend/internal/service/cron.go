@@ -2,8 +2,11 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"anyrouter-checkin/internal/model"
 	"anyrouter-checkin/internal/repository"
@@ -13,15 +16,44 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	TaskTypeCheckin   = "checkin"
+	TaskTypeUsageSync = "usage_sync"
+)
+
+var ErrInvalidTaskType = errors.New("任务类型无效，仅支持 checkin、usage_sync")
+
 var (
 	scheduler *cron.Cron
 	taskIDs   = make(map[uint]cron.EntryID)
 	mu        sync.Mutex
 )
 
+func init() {
+	OnConfigChange(func(keys []string) {
+		if !slices.Contains(keys, "scheduler.timezone") {
+			return
+		}
+		mu.Lock()
+		started := scheduler != nil
+		if started {
+			scheduler.Stop()
+		}
+		mu.Unlock()
+		if started {
+			InitCron()
+			zap.L().Info("定时任务时区已更新", zap.String("timezone", cronLocation().String()))
+		}
+	})
+}
+
+// InitCron 按 scheduler.timezone 创建调度器并注册已启用的任务，时区变更后会重新调用。
 func InitCron() {
-	scheduler = cron.New()
+	mu.Lock()
+	scheduler = cron.New(cron.WithLocation(cronLocation()))
+	taskIDs = make(map[uint]cron.EntryID)
 	scheduler.Start()
+	mu.Unlock()
 
 	tasks, err := repository.ListEnabledCronTasks()
 	if err != nil {
@@ -74,16 +106,32 @@ func ExecuteTask(taskID uint) {
 	if err := json.Unmarshal([]byte(task.AccountIDs), &accountIDs); err != nil {
 		return
 	}
+	// 账号转交后不再属于任务所有者可见范围的，不再执行。
+	scope, err := ResolveScope(task.OwnerID)
+	if err != nil {
+		zap.L().Warn("获取任务所有者失败", zap.Uint("task_id", task.ID), zap.Uint("owner_id", task.OwnerID), zap.Error(err))
+		return
+	}
 
+	enabled := make([]uint, 0, len(accountIDs))
 	for _, accID := range accountIDs {
 		account, err := repository.GetAccountByID(accID)
 		if err != nil {
 			continue
 		}
-		if account.Status != 1 {
+		if account.Status != 1 || !scope.Allows(account.OwnerID) {
 			continue
 		}
-		CheckinAccount(accID)
+		enabled = append(enabled, accID)
+	}
+
+	switch task.TaskType {
+	case TaskTypeUsageSync:
+		syncUsageForAccounts(enabled)
+	default:
+		for _, accID := range enabled {
+			CheckinAccount(accID)
+		}
 	}
 
 	now := carbon.DateTime{Carbon: carbon.Now()}
@@ -106,7 +154,7 @@ func updateNextRun(taskID uint) {
 		return
 	}
 
-	nextTime := schedule.Next(carbon.Now().StdTime())
+	nextTime := schedule.Next(carbon.Now().SetLocation(cronLocation()).StdTime())
 	next := carbon.DateTime{Carbon: carbon.CreateFromStdTime(nextTime)}
 	task.NextRun = &next
 	if err := repository.SaveCronTask(task); err != nil {
@@ -114,8 +162,17 @@ func updateNextRun(taskID uint) {
 	}
 }
 
-func listCronTasks() ([]model.CronTask, error) {
-	return repository.ListCronTasks()
+// cronLocation 返回 scheduler.timezone 对应的时区，未设置时使用系统时区。
+func cronLocation() *time.Location {
+	name := GetConfig("scheduler.timezone")
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
 }
 
 func removeAccountFromCronTasks(accountID uint) error {
@@ -165,6 +222,18 @@ func parseAccountIDs(raw string) ([]uint, error) {
 	return ids, nil
 }
 
+// normalizeTaskType 校验任务类型，为空时视为签到任务。
+func normalizeTaskType(taskType string) (string, error) {
+	switch strings.TrimSpace(taskType) {
+	case "", TaskTypeCheckin:
+		return TaskTypeCheckin, nil
+	case TaskTypeUsageSync:
+		return TaskTypeUsageSync, nil
+	default:
+		return "", ErrInvalidTaskType
+	}
+}
+
 func createCronTask(task *model.CronTask) (model.CronTask, error) {
 	if err := repository.CreateCronTask(task); err != nil {
 		return model.CronTask{}, err
@@ -181,6 +250,9 @@ func updateCronTask(id uint, req model.CronTask) (model.CronTask, error) {
 	task.CronExpr = req.CronExpr
 	task.AccountIDs = req.AccountIDs
 	task.Status = req.Status
+	if req.TaskType != "" {
+		task.TaskType = req.TaskType
+	}
 	if err := repository.SaveCronTask(task); err != nil {
 		return model.CronTask{}, err
 	}
@@ -1,12 +1,24 @@
 package service
 
-import "anyrouter-checkin/internal/model"
+import (
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+)
 
-func ListCronTasks() ([]model.CronTask, error) {
-	return listCronTasks()
+func ListCronTasks(scope Scope) ([]model.CronTask, error) {
+	return repository.ListCronTasksInScope(scope)
 }
 
-func CreateCronTask(task model.CronTask) (model.CronTask, error) {
+// CreateCronTask 创建定时任务，任务中的账号需在创建者的可见范围内。
+func CreateCronTask(scope Scope, task model.CronTask) (model.CronTask, error) {
+	taskType, err := normalizeTaskType(task.TaskType)
+	if err != nil {
+		return model.CronTask{}, err
+	}
+	if err := checkTaskAccounts(scope, task.AccountIDs); err != nil {
+		return model.CronTask{}, err
+	}
+	task.TaskType = taskType
 	created, err := createCronTask(&task)
 	if err != nil {
 		return model.CronTask{}, err
@@ -19,7 +31,20 @@ func CreateCronTask(task model.CronTask) (model.CronTask, error) {
 	return created, nil
 }
 
-func UpdateCronTask(id uint, req model.CronTask) (model.CronTask, error) {
+func UpdateCronTask(scope Scope, id uint, req model.CronTask) (model.CronTask, error) {
+	if err := CheckCronTaskAccess(scope, id); err != nil {
+		return model.CronTask{}, err
+	}
+	if err := checkTaskAccounts(scope, req.AccountIDs); err != nil {
+		return model.CronTask{}, err
+	}
+	if req.TaskType != "" {
+		taskType, err := normalizeTaskType(req.TaskType)
+		if err != nil {
+			return model.CronTask{}, err
+		}
+		req.TaskType = taskType
+	}
 	updated, err := updateCronTask(id, req)
 	if err != nil {
 		return model.CronTask{}, err
@@ -34,7 +59,10 @@ func UpdateCronTask(id uint, req model.CronTask) (model.CronTask, error) {
 	return updated, nil
 }
 
-func DeleteCronTask(id uint) error {
+func DeleteCronTask(scope Scope, id uint) error {
+	if err := CheckCronTaskAccess(scope, id); err != nil {
+		return err
+	}
 	UnregisterTask(id)
 	return deleteCronTask(id)
 }
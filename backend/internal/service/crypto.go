@@ -0,0 +1,67 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"anyrouter-checkin/internal/config"
+)
+
+// encryptSecret 使用 aes.key 派生的密钥以 AES-GCM 加密敏感字段，结果为 Base64。
+func encryptSecret(plaintext string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	return sealSecret(gcm, plaintext)
+}
+
+// sealSecret 使用随机 nonce 加密，结果为 Base64 编码的 nonce 与密文。
+func sealSecret(gcm cipher.AEAD, plaintext string) (string, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(ciphertext string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	return openSecret(gcm, ciphertext)
+}
+
+// openSecret 解密 Base64 编码的 nonce 与密文。
+func openSecret(gcm cipher.AEAD, encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("密文无效")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	if config.Get().AES.Key == "" {
+		return nil, errors.New("未配置 aes.key")
+	}
+	key := sha256.Sum256([]byte(config.Get().AES.Key))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
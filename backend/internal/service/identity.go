@@ -0,0 +1,57 @@
+package service
+
+import (
+	"strings"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
+
+	"go.uber.org/zap"
+)
+
+var ErrInvalidProxyURL = upstream.ErrInvalidProxyURL
+
+// resolveIdentity 根据账号的出口代理与指纹配置生成上游身份，未配置指纹时使用默认值。
+func resolveIdentity(account *model.Account) upstream.Identity {
+	identity := upstream.DefaultIdentity()
+	identity.ProxyURL = strings.TrimSpace(account.ProxyURL)
+	if account.ProfileID == nil {
+		return identity
+	}
+	profile, err := repository.GetBrowserProfileByID(*account.ProfileID)
+	if err != nil {
+		zap.L().Warn("读取指纹配置失败，使用默认配置", zap.Uint("account_id", account.ID), zap.Error(err))
+		return identity
+	}
+	if profile.UserAgent != "" {
+		identity.UserAgent = profile.UserAgent
+	}
+	identity.SecChUa = profile.SecChUa
+	identity.SecChUaMobile = profile.SecChUaMobile
+	identity.SecChUaPlatform = profile.SecChUaPlatform
+	if profile.AcceptLanguage != "" {
+		identity.AcceptLanguage = profile.AcceptLanguage
+	}
+	return identity
+}
+
+// accountCredential 组装访问上游所需的登录态，userID 为 0 时不发送 new-api-user 头。
+// 上游轮换 session 时同步更新内存中的账号并持久化，避免后续保存覆盖新值。
+func accountCredential(account *model.Account, sessionCookie string, userID int) upstream.Credential {
+	return upstream.Credential{
+		Session:  extractSessionValue(sessionCookie),
+		UserID:   userID,
+		Identity: resolveIdentity(account),
+		OnSessionRotated: func(session string) {
+			if account.ID == 0 || sessionCookie != account.Session {
+				return
+			}
+			account.Session = session
+			sessionCookie = session
+			if err := repository.UpdateAccountSession(account.ID, session); err != nil {
+				zap.L().Warn("保存轮换后的 Session 失败", zap.Uint("account_id", account.ID), zap.Error(err))
+			}
+		},
+	}
+}
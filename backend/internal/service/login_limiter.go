@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginLimiter 记录登录失败次数并在超过阈值后锁定，key 为客户端 IP 或用户名。
+type LoginLimiter interface {
+	// Locked 返回 key 是否处于锁定中以及剩余锁定时间。
+	Locked(key string) (time.Duration, bool)
+	// Fail 记录一次失败，若因此进入锁定则返回锁定时长。
+	Fail(key string) time.Duration
+	// Reset 清除 key 的失败记录。
+	Reset(key string)
+}
+
+// LoginLimitPolicy 为锁定策略：连续失败 MaxFailures 次后锁定 BaseLockout，
+// 每次再被锁定时长加倍，最长 MaxLockout；超过 ResetAfter 没有失败则清零。
+type LoginLimitPolicy struct {
+	MaxFailures int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	ResetAfter  time.Duration
+}
+
+type loginAttemptState struct {
+	failures    int
+	lockouts    int
+	lockedUntil *carbon.Carbon
+	lastFailure *carbon.Carbon
+}
+
+// MemoryLoginLimiter 为进程内的 LoginLimiter 实现，重启后记录清空。
+type MemoryLoginLimiter struct {
+	policy    LoginLimitPolicy
+	mu        sync.Mutex
+	states    map[string]*loginAttemptState
+	lastSweep *carbon.Carbon
+}
+
+func NewMemoryLoginLimiter(policy LoginLimitPolicy) *MemoryLoginLimiter {
+	return &MemoryLoginLimiter{
+		policy: policy,
+		states: make(map[string]*loginAttemptState),
+	}
+}
+
+func (l *MemoryLoginLimiter) Locked(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.states[key]
+	if state == nil || state.lockedUntil == nil {
+		return 0, false
+	}
+	remaining := carbon.Now().DiffInDuration(state.lockedUntil)
+	return remaining, remaining > 0
+}
+
+func (l *MemoryLoginLimiter) Fail(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := carbon.Now()
+	l.sweepLocked(now)
+
+	state := l.states[key]
+	if state == nil || state.lastFailure.DiffInDuration(now) > l.policy.ResetAfter {
+		state = &loginAttemptState{}
+		l.states[key] = state
+	}
+	state.lastFailure = now
+	state.failures++
+	if state.failures < l.policy.MaxFailures {
+		return 0
+	}
+
+	lockout := l.policy.BaseLockout << min(state.lockouts, 16)
+	if lockout <= 0 || lockout > l.policy.MaxLockout {
+		lockout = l.policy.MaxLockout
+	}
+	state.failures = 0
+	state.lockouts++
+	state.lockedUntil = now.AddSeconds(int(lockout.Seconds()))
+	return lockout
+}
+
+func (l *MemoryLoginLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.states, key)
+}
+
+// sweepLocked 每分钟最多清理一次长时间没有失败的记录，避免内存持续增长。
+func (l *MemoryLoginLimiter) sweepLocked(now *carbon.Carbon) {
+	if l.lastSweep != nil && l.lastSweep.DiffInDuration(now) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, state := range l.states {
+		if state.lastFailure.DiffInDuration(now) > l.policy.ResetAfter && (state.lockedUntil == nil || now.Gt(state.lockedUntil)) {
+			delete(l.states, key)
+		}
+	}
+}
+
+// 失败原因，仅记录在失败记录中，不返回给调用方。
+const (
+	LoginFailureUnknownUser   = "用户不存在"
+	LoginFailureWrongPassword = "密码错误"
+	LoginFailureWrongCode     = "动态码错误"
+	LoginFailureLocked        = "已锁定"
+
+	loginAttemptRetentionDays = 30
+)
+
+// ErrInvalidCredentials 为用户不存在与密码错误时统一返回的错误，避免枚举用户名。
+var ErrInvalidCredentials = errors.New("用户名或密码错误")
+
+// LoginLockedError 表示失败次数过多，需等待 RetryAfter 后重试。
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	minutes := int(math.Ceil(e.RetryAfter.Minutes()))
+	return fmt.Sprintf("登录失败次数过多，请 %d 分钟后再试", max(minutes, 1))
+}
+
+var (
+	// loginUserLimiter 按用户名计数：连续失败 5 次锁定 1 分钟，之后每次加倍，最长 1 小时。
+	loginUserLimiter LoginLimiter = NewMemoryLoginLimiter(LoginLimitPolicy{
+		MaxFailures: 5,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+		ResetAfter:  time.Hour,
+	})
+	// loginIPLimiter 按客户端 IP 计数，阈值更高以兼容多人共用出口 IP。
+	loginIPLimiter LoginLimiter = NewMemoryLoginLimiter(LoginLimitPolicy{
+		MaxFailures: 20,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+		ResetAfter:  time.Hour,
+	})
+
+	dummyPasswordHash = sync.OnceValue(func() []byte {
+		hash, err := bcrypt.GenerateFromPassword([]byte("anyrouter-dummy-password"), bcrypt.DefaultCost)
+		if err != nil {
+			// 固定口令与默认成本下不会失败，失败说明 bcrypt 不可用，继续运行会让计时防护失效。
+			panic(fmt.Sprintf("生成占位密码哈希失败: %v", err))
+		}
+		return hash
+	})
+
+	lastLoginAttemptPrune atomic.Int64
+)
+
+// SetLoginLimiters 替换按用户名与按 IP 计数的限制器，便于测试或接入外部存储。
+func SetLoginLimiters(user, ip LoginLimiter) {
+	loginUserLimiter = user
+	loginIPLimiter = ip
+}
+
+func checkLoginLocked(username, ip string) error {
+	remaining, locked := loginIPLimiter.Locked(ip)
+	if userRemaining, userLocked := loginUserLimiter.Locked(strings.ToLower(username)); userLocked {
+		locked = true
+		remaining = max(remaining, userRemaining)
+	}
+	if !locked {
+		return nil
+	}
+	recordLoginFailure(username, ip, LoginFailureLocked)
+	return &LoginLockedError{RetryAfter: remaining}
+}
+
+// failLogin 记录一次失败并计数，返回应告知调用方的错误。
+func failLogin(username, ip, reason string) error {
+	recordLoginFailure(username, ip, reason)
+	lockout := max(loginUserLimiter.Fail(strings.ToLower(username)), loginIPLimiter.Fail(ip))
+	if lockout > 0 {
+		zap.L().Warn("登录失败次数过多，已锁定", zap.String("username", username), zap.String("ip", ip), zap.Duration("lockout", lockout))
+		return &LoginLockedError{RetryAfter: lockout}
+	}
+	return ErrInvalidCredentials
+}
+
+// resetLoginFailures 在登录成功后清除用户名的失败计数；IP 计数不清除，避免用自己的账号为暴力破解解锁。
+func resetLoginFailures(username string) {
+	loginUserLimiter.Reset(strings.ToLower(username))
+}
+
+func recordLoginFailure(username, ip, reason string) {
+	attempt := model.LoginAttempt{
+		Username: truncateMessage(username, 50),
+		IP:       truncateMessage(ip, 64),
+		Reason:   reason,
+	}
+	if err := repository.CreateLoginAttempt(&attempt); err != nil {
+		zap.L().Warn("记录登录失败失败", zap.Error(err))
+	}
+
+	// 每小时最多清理一次过期记录。
+	now := carbon.Now()
+	last := lastLoginAttemptPrune.Load()
+	if now.Timestamp()-last < 3600 || !lastLoginAttemptPrune.CompareAndSwap(last, now.Timestamp()) {
+		return
+	}
+	if err := repository.DeleteLoginAttemptsBefore(now.SubDays(loginAttemptRetentionDays)); err != nil {
+		zap.L().Warn("清理登录失败记录失败", zap.Error(err))
+	}
+}
+
+// ListLoginAttempts 返回最近的登录失败记录，可按用户名或 IP 过滤。
+func ListLoginAttempts(username, ip string, limit int) ([]model.LoginAttempt, error) {
+	return repository.ListLoginAttempts(strings.TrimSpace(username), strings.TrimSpace(ip), limit)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+)
+
+func TestMemoryLoginLimiter(t *testing.T) {
+	defer carbon.ClearTestNow()
+	start := carbon.Parse("2026-01-01 08:00:00")
+	carbon.SetTestNow(start)
+	limiter := NewMemoryLoginLimiter(LoginLimitPolicy{MaxFailures: 3, BaseLockout: time.Minute, MaxLockout: 4 * time.Minute, ResetAfter: time.Hour})
+
+	failTimes := func(n int) time.Duration {
+		var lockout time.Duration
+		for range n {
+			lockout = limiter.Fail("alice")
+		}
+		return lockout
+	}
+
+	if lockout := failTimes(2); lockout != 0 {
+		t.Fatalf("未达阈值不应锁定: %v", lockout)
+	}
+	if _, locked := limiter.Locked("alice"); locked {
+		t.Fatal("未达阈值不应锁定")
+	}
+	if lockout := failTimes(1); lockout != time.Minute {
+		t.Fatalf("首次锁定 = %v, want 1m", lockout)
+	}
+	carbon.SetTestNow(start.AddSeconds(30))
+	if remaining, locked := limiter.Locked("alice"); !locked || remaining != 30*time.Second {
+		t.Fatalf("锁定剩余 = %v %v, want 30s", remaining, locked)
+	}
+	carbon.SetTestNow(start.AddMinutes(1))
+	if _, locked := limiter.Locked("alice"); locked {
+		t.Fatal("锁定到期后应解除")
+	}
+
+	// 再次锁定时长加倍，且不超过 MaxLockout。
+	for i, want := range []time.Duration{2 * time.Minute, 4 * time.Minute, 4 * time.Minute} {
+		if lockout := failTimes(3); lockout != want {
+			t.Fatalf("第 %d 次再锁定 = %v, want %v", i+1, lockout, want)
+		}
+	}
+
+	// 超过 ResetAfter 没有失败后计数清零。
+	carbon.SetTestNow(start.AddHours(2))
+	if lockout := failTimes(2); lockout != 0 {
+		t.Fatalf("计数清零后不应锁定: %v", lockout)
+	}
+	limiter.Reset("alice")
+	if lockout := failTimes(2); lockout != 0 {
+		t.Fatalf("Reset 后不应锁定: %v", lockout)
+	}
+	if _, locked := limiter.Locked("bob"); locked {
+		t.Fatal("其他 key 不应受影响")
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/middleware"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	refreshTokenPrefix      = "arr_"
+	refreshTokenRandomBytes = 32
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("登录已失效，请重新登录")
+
+	lastLoginSessionPrune atomic.Int64
+)
+
+// LoginResult 为登录结果：未开启两步验证时直接返回访问令牌与刷新令牌，
+// 否则返回 ChallengeToken，需提交动态码后换取令牌。ExpiresIn 为访问令牌的有效秒数。
+type LoginResult struct {
+	Token             string `json:"token,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	ExpiresIn         int64  `json:"expires_in,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
+// LoginClient 为发起登录的客户端信息，记录在会话中便于用户辨认。
+type LoginClient struct {
+	IP        string
+	UserAgent string
+}
+
+// LoginSessionInfo 为会话列表项，Current 表示发起请求的会话。
+type LoginSessionInfo struct {
+	model.LoginSession
+	Current bool `json:"current"`
+}
+
+// startLoginSession 为已通过认证的用户创建会话并签发令牌。
+func startLoginSession(user *model.User, client LoginClient) (LoginResult, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return LoginResult{}, err
+	}
+	session := model.LoginSession{
+		UserID:      user.ID,
+		RefreshHash: hashRefreshToken(refreshToken),
+		IP:          truncateMessage(client.IP, 64),
+		UserAgent:   truncateMessage(client.UserAgent, 255),
+		ExpiresAt:   carbon.DateTime{Carbon: refreshExpiresAt()},
+	}
+	if err := repository.CreateLoginSession(&session); err != nil {
+		return LoginResult{}, err
+	}
+	pruneLoginSessions()
+
+	token, err := generateToken(user, session.ID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	return LoginResult{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(config.Get().JWT.Expire.Seconds()),
+	}, nil
+}
+
+// RefreshLoginSession 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换。
+// 已轮换的旧刷新令牌再次出现说明可能已泄露，此时吊销整个会话。
+func RefreshLoginSession(refreshToken string, client LoginClient) (LoginResult, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if !strings.HasPrefix(refreshToken, refreshTokenPrefix) {
+		return LoginResult{}, ErrInvalidRefreshToken
+	}
+	hash := hashRefreshToken(refreshToken)
+	session, err := repository.GetLoginSessionByRefreshHash(hash)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return LoginResult{}, err
+		}
+		if reused, err := repository.GetLoginSessionByPreviousHash(hash); err == nil {
+			zap.L().Warn("刷新令牌被重复使用，已吊销会话", zap.Uint("user_id", reused.UserID), zap.Uint("session_id", reused.ID), zap.String("ip", client.IP))
+			if _, err := repository.DeleteLoginSession(reused.UserID, reused.ID); err != nil {
+				return LoginResult{}, err
+			}
+		}
+		return LoginResult{}, ErrInvalidRefreshToken
+	}
+	if session.ExpiresAt.Lt(carbon.Now()) {
+		if _, err := repository.DeleteLoginSession(session.UserID, session.ID); err != nil {
+			zap.L().Warn("删除过期会话失败", zap.Uint("session_id", session.ID), zap.Error(err))
+		}
+		return LoginResult{}, ErrInvalidRefreshToken
+	}
+	user, err := repository.GetUserByID(session.UserID)
+	if err != nil || user.Status != 1 {
+		return LoginResult{}, ErrInvalidRefreshToken
+	}
+
+	newToken, err := generateRefreshToken()
+	if err != nil {
+		return LoginResult{}, err
+	}
+	rotated, err := repository.RotateLoginSession(session.ID, hash, hashRefreshToken(newToken), truncateMessage(client.IP, 64), refreshExpiresAt())
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if !rotated {
+		return LoginResult{}, ErrInvalidRefreshToken
+	}
+
+	token, err := generateToken(user, session.ID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	return LoginResult{
+		Token:        token,
+		RefreshToken: newToken,
+		ExpiresIn:    int64(config.Get().JWT.Expire.Seconds()),
+	}, nil
+}
+
+func ListLoginSessions(userID, currentID uint) ([]LoginSessionInfo, error) {
+	sessions, err := repository.ListLoginSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := carbon.Now()
+	items := make([]LoginSessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		if session.ExpiresAt.Lt(now) {
+			continue
+		}
+		items = append(items, LoginSessionInfo{LoginSession: session, Current: session.ID == currentID})
+	}
+	return items, nil
+}
+
+// RevokeLoginSession 吊销用户自己的会话，该会话的访问令牌与刷新令牌立即失效。
+func RevokeLoginSession(userID, id uint) error {
+	found, err := repository.DeleteLoginSession(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeOtherLoginSessions 吊销除当前会话外的全部会话。
+func RevokeOtherLoginSessions(userID, currentID uint) error {
+	return repository.DeleteUserLoginSessions(userID, currentID)
+}
+
+// refreshExpiresAt 返回此刻签发的刷新令牌的过期时间。
+func refreshExpiresAt() *carbon.Carbon {
+	return carbon.Now().AddSeconds(int(config.Get().JWT.RefreshExpire.Seconds()))
+}
+
+func generateToken(user *model.User, sessionID uint) (string, error) {
+	now := carbon.Now().StdTime()
+	claims := middleware.Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		SessionID:    sessionID,
+		TokenVersion: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(config.Get().JWT.Expire)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.Get().JWT.Secret))
+}
+
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, refreshTokenRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return refreshTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// pruneLoginSessions 每小时最多清理一次已过期的会话。
+func pruneLoginSessions() {
+	now := carbon.Now()
+	last := lastLoginSessionPrune.Load()
+	if now.Timestamp()-last < 3600 || !lastLoginSessionPrune.CompareAndSwap(last, now.Timestamp()) {
+		return
+	}
+	if err := repository.DeleteLoginSessionsExpiredBefore(now); err != nil {
+		zap.L().Warn("清理过期会话失败", zap.Error(err))
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/middleware"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/oidc"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// OIDCStateTTLSeconds 为发起单点登录到回调之间的有效秒数。
+	OIDCStateTTLSeconds = 600
+	oidcLoginTimeout    = 15 * time.Second
+)
+
+var (
+	ErrOIDCDisabled        = errors.New("未启用单点登录")
+	ErrOIDCState           = errors.New("单点登录已失效，请重新登录")
+	ErrOIDCNoRole          = errors.New("所在用户组未被授权访问")
+	ErrOIDCNotProvisioned  = errors.New("用户未开通，请联系管理员")
+	ErrOIDCUsernameTaken   = errors.New("用户名已被本地账号占用，请联系管理员")
+	ErrLocalLoginDisabled  = errors.New("已禁用本地密码登录，请使用单点登录")
+	ErrOIDCProviderFailure = errors.New("单点登录失败")
+)
+
+// AuthMethods 为登录页可用的登录方式。
+type AuthMethods struct {
+	LocalLogin bool `json:"local_login"`
+	OIDC       bool `json:"oidc"`
+}
+
+// oidcPending 为发起单点登录时生成的校验参数，加密后保存在浏览器的 state Cookie 中，
+// 服务端不保存，未登录的请求无法占用服务端内存。
+type oidcPending struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+var (
+	oidcMu       sync.Mutex
+	oidcProvider *oidc.Provider
+)
+
+func GetAuthMethods() AuthMethods {
+	return AuthMethods{LocalLogin: localLoginEnabled(), OIDC: config.Get().OIDC.Enabled}
+}
+
+// localLoginEnabled 未启用单点登录时本地密码登录始终可用，避免误配置导致无法登录。
+func localLoginEnabled() bool {
+	return !config.Get().OIDC.Enabled || config.Get().OIDC.LocalLogin
+}
+
+// StartOIDCLogin 生成 state、nonce 与 PKCE code_verifier，返回身份提供方的授权地址与加密后的校验参数。
+// 调用方需把校验参数保存在发起登录的浏览器中，回调时交给 CompleteOIDCLogin，防止登录 CSRF。
+func StartOIDCLogin(loginHint string) (string, string, error) {
+	provider, err := getOIDCProvider()
+	if err != nil {
+		return "", "", err
+	}
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcLoginTimeout)
+	defer cancel()
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier, strings.TrimSpace(loginHint))
+	if err != nil {
+		zap.L().Error("读取 OIDC 配置失败", zap.Error(err))
+		return "", "", ErrOIDCProviderFailure
+	}
+
+	data, err := json.Marshal(oidcPending{
+		State:     state,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: carbon.Now().AddSeconds(OIDCStateTTLSeconds).Timestamp(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := encryptSecret(string(data))
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, sealed, nil
+}
+
+// CompleteOIDCLogin 解密发起登录时保存在浏览器中的校验参数 sealed，校验回调的 state 与之一致且未过期，
+// 使用授权码换取并校验 ID Token，按用户组映射角色并在首次登录时自动开通用户，成功后签发登录令牌。
+// 授权码只能使用一次，同一组参数无法重放。
+func CompleteOIDCLogin(sealed, state, code string, client LoginClient) (LoginResult, error) {
+	provider, err := getOIDCProvider()
+	if err != nil {
+		return LoginResult{}, err
+	}
+	pending, err := openOIDCPending(sealed)
+	if err != nil || subtle.ConstantTimeCompare([]byte(pending.State), []byte(state)) != 1 ||
+		carbon.Now().Timestamp() > pending.ExpiresAt || code == "" {
+		return LoginResult{}, ErrOIDCState
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcLoginTimeout)
+	defer cancel()
+	rawIDToken, err := provider.Exchange(ctx, code, pending.Verifier)
+	if err != nil {
+		zap.L().Warn("OIDC 授权码换取令牌失败", zap.Error(err))
+		return LoginResult{}, ErrOIDCProviderFailure
+	}
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, pending.Nonce)
+	if err != nil {
+		zap.L().Warn("OIDC ID Token 校验失败", zap.Error(err))
+		return LoginResult{}, ErrOIDCProviderFailure
+	}
+
+	user, err := provisionOIDCUser(claims)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	return startLoginSession(user, client)
+}
+
+func openOIDCPending(sealed string) (oidcPending, error) {
+	var pending oidcPending
+	if sealed == "" {
+		return pending, ErrOIDCState
+	}
+	data, err := decryptSecret(sealed)
+	if err != nil {
+		return pending, err
+	}
+	err = json.Unmarshal([]byte(data), &pending)
+	return pending, err
+}
+
+// provisionOIDCUser 按 subject 查找用户，不存在时按配置关联同名本地用户或自动开通，并同步角色。
+func provisionOIDCUser(claims oidc.Claims) (*model.User, error) {
+	cfg := config.Get().OIDC
+	subject := claims.String("sub")
+	if subject == "" {
+		return nil, ErrOIDCProviderFailure
+	}
+	role := oidcRole(claims.Strings(cfg.GroupsClaim))
+	if role == "" {
+		zap.L().Warn("OIDC 用户组未映射到角色", zap.String("subject", subject), zap.Strings("groups", claims.Strings(cfg.GroupsClaim)))
+		return nil, ErrOIDCNoRole
+	}
+
+	user, err := repository.GetUserByOIDCSubject(subject)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if user, err = linkOrCreateOIDCUser(oidcUsername(claims), subject, role); err != nil {
+			return nil, err
+		}
+	}
+	if user.Status != 1 {
+		return nil, ErrUserDisabled
+	}
+
+	if user.Role != role {
+		if user.Role == model.RoleAdmin {
+			count, err := repository.CountActiveAdmins(user.ID)
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				zap.L().Warn("OIDC 角色变更会移除最后一个管理员，保留原角色", zap.String("username", user.Username), zap.String("role", role))
+				return user, nil
+			}
+		}
+		user.Role = role
+		if err := repository.SaveUser(user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func linkOrCreateOIDCUser(username, subject, role string) (*model.User, error) {
+	cfg := config.Get().OIDC
+	existing, err := repository.GetUserByUsername(username)
+	if err == nil {
+		if !cfg.LinkExisting || existing.OIDCSubject != "" {
+			return nil, ErrOIDCUsernameTaken
+		}
+		existing.OIDCSubject = subject
+		if err := repository.SaveUser(existing); err != nil {
+			return nil, err
+		}
+		zap.L().Info("OIDC 用户已关联本地用户", zap.String("username", username))
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if !cfg.AutoProvision {
+		return nil, ErrOIDCNotProvisioned
+	}
+
+	// 单点登录用户不使用本地密码，写入随机密码的哈希。
+	password, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user := model.User{
+		Username:    username,
+		Password:    hashed,
+		Role:        role,
+		Status:      1,
+		OIDCSubject: subject,
+	}
+	if err := repository.CreateUser(&user); err != nil {
+		return nil, err
+	}
+	zap.L().Info("OIDC 用户已自动开通", zap.String("username", username), zap.String("role", role))
+	return &user, nil
+}
+
+// oidcRole 返回用户组映射到的最高角色，未匹配任何组时使用 default_role。
+func oidcRole(groups []string) string {
+	cfg := config.Get().OIDC
+	for _, mapping := range []struct {
+		groups []string
+		role   string
+	}{
+		{cfg.AdminGroups, model.RoleAdmin},
+		{cfg.OperatorGroups, model.RoleOperator},
+		{cfg.ViewerGroups, model.RoleViewer},
+	} {
+		for _, group := range groups {
+			if slices.Contains(mapping.groups, group) {
+				return mapping.role
+			}
+		}
+	}
+	if middleware.ValidRole(cfg.DefaultRole) {
+		return cfg.DefaultRole
+	}
+	return ""
+}
+
+// oidcUsername 依次使用 username_claim、email 与 sub 作为用户名。
+func oidcUsername(claims oidc.Claims) string {
+	username := ""
+	for _, name := range []string{config.Get().OIDC.UsernameClaim, "email", "sub"} {
+		if username = strings.TrimSpace(claims.String(name)); username != "" {
+			break
+		}
+	}
+	if utf8.RuneCountInString(username) > 50 {
+		username = string([]rune(username)[:50])
+	}
+	return username
+}
+
+// getOIDCProvider 返回当前配置对应的身份提供方客户端，配置变更后重新创建。
+func getOIDCProvider() (*oidc.Provider, error) {
+	cfg := config.Get().OIDC
+	if !cfg.Enabled {
+		return nil, ErrOIDCDisabled
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("%w: 缺少 issuer、client_id 或 redirect_url", ErrOIDCDisabled)
+	}
+	want := oidc.Config{
+		Issuer:       strings.TrimRight(cfg.Issuer, "/"),
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	}
+
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	if oidcProvider != nil {
+		current := oidcProvider.Config()
+		if current.Issuer == want.Issuer && current.ClientID == want.ClientID && current.ClientSecret == want.ClientSecret &&
+			current.RedirectURL == want.RedirectURL && slices.Equal(current.Scopes, want.Scopes) {
+			return oidcProvider, nil
+		}
+	}
+	oidcProvider = oidc.NewProvider(want)
+	return oidcProvider, nil
+}
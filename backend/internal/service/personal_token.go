@@ -0,0 +1,100 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"anyrouter-checkin/internal/middleware"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	personalTokenRandomBytes = 24
+	maxPersonalTokenDays     = 365
+)
+
+var ErrInvalidPersonalToken = errors.New("令牌参数无效")
+
+// PersonalTokenCreated 为新建的令牌，完整令牌仅在创建时返回一次。
+type PersonalTokenCreated struct {
+	model.PersonalToken
+	Token string `json:"token"`
+}
+
+func ListPersonalTokens(userID uint) ([]model.PersonalToken, error) {
+	return repository.ListPersonalTokens(userID)
+}
+
+// CreatePersonalToken 为用户签发个人访问令牌，权限范围不能超过用户当前角色，expiresInDays 为 0 表示永不过期。
+func CreatePersonalToken(userID uint, name string, scopes []string, expiresInDays int) (PersonalTokenCreated, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return PersonalTokenCreated{}, fmt.Errorf("%w: 名称不能为空且不超过 100 个字符", ErrInvalidPersonalToken)
+	}
+	if expiresInDays < 0 || expiresInDays > maxPersonalTokenDays {
+		return PersonalTokenCreated{}, fmt.Errorf("%w: 有效期需为 0~%d 天", ErrInvalidPersonalToken, maxPersonalTokenDays)
+	}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !middleware.ValidTokenScope(scope) {
+			return PersonalTokenCreated{}, fmt.Errorf("%w: 权限范围仅支持 read、checkin、admin", ErrInvalidPersonalToken)
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return PersonalTokenCreated{}, fmt.Errorf("%w: 至少需要一个权限范围", ErrInvalidPersonalToken)
+	}
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return PersonalTokenCreated{}, err
+	}
+	scopeString := strings.Join(normalized, ",")
+	if !middleware.HasRole(user.Role, middleware.TokenScopeRole(scopeString)) {
+		return PersonalTokenCreated{}, fmt.Errorf("%w: 权限范围超出当前角色", ErrInvalidPersonalToken)
+	}
+
+	buf := make([]byte, personalTokenRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return PersonalTokenCreated{}, err
+	}
+	raw := middleware.PersonalTokenPrefix + hex.EncodeToString(buf)
+	token := model.PersonalToken{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   middleware.HashPersonalToken(raw),
+		TokenPrefix: raw[:len(middleware.PersonalTokenPrefix)+4],
+		Scopes:      scopeString,
+	}
+	if expiresInDays > 0 {
+		expiresAt := carbon.DateTime{Carbon: carbon.Now().AddDays(expiresInDays)}
+		token.ExpiresAt = &expiresAt
+	}
+	if err := repository.CreatePersonalToken(&token); err != nil {
+		return PersonalTokenCreated{}, err
+	}
+	return PersonalTokenCreated{PersonalToken: token, Token: raw}, nil
+}
+
+// RevokePersonalToken 吊销用户自己的令牌。
+func RevokePersonalToken(userID, id uint) error {
+	found, err := repository.DeletePersonalToken(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
+)
+
+var ErrProfileInUse = errors.New("指纹配置仍被账号使用")
+var ErrInvalidProfile = errors.New("指纹配置无效：名称与 User-Agent 不能为空")
+
+func ListBrowserProfiles() ([]model.BrowserProfile, error) {
+	return repository.ListBrowserProfiles()
+}
+
+func CreateBrowserProfile(profile model.BrowserProfile) (model.BrowserProfile, error) {
+	if err := validateBrowserProfile(&profile); err != nil {
+		return model.BrowserProfile{}, err
+	}
+	profile.ID = 0
+	if err := repository.CreateBrowserProfile(&profile); err != nil {
+		return model.BrowserProfile{}, err
+	}
+	return profile, nil
+}
+
+func UpdateBrowserProfile(id uint, req model.BrowserProfile) (model.BrowserProfile, error) {
+	profile, err := repository.GetBrowserProfileByID(id)
+	if err != nil {
+		return model.BrowserProfile{}, err
+	}
+	if err := validateBrowserProfile(&req); err != nil {
+		return model.BrowserProfile{}, err
+	}
+	profile.Name = req.Name
+	profile.UserAgent = req.UserAgent
+	profile.SecChUa = req.SecChUa
+	profile.SecChUaMobile = req.SecChUaMobile
+	profile.SecChUaPlatform = req.SecChUaPlatform
+	profile.AcceptLanguage = req.AcceptLanguage
+	if err := repository.SaveBrowserProfile(profile); err != nil {
+		return model.BrowserProfile{}, err
+	}
+	return *profile, nil
+}
+
+func DeleteBrowserProfile(id uint) error {
+	count, err := repository.CountAccountsByProfile(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrProfileInUse
+	}
+	return repository.DeleteBrowserProfile(id)
+}
+
+func validateBrowserProfile(profile *model.BrowserProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	profile.UserAgent = strings.TrimSpace(profile.UserAgent)
+	if profile.Name == "" || profile.UserAgent == "" {
+		return ErrInvalidProfile
+	}
+	return nil
+}
+
+// UpdateAccountNetwork 设置账号的出口代理与指纹配置，profileID 为空时使用默认指纹。
+func UpdateAccountNetwork(id uint, proxyURL string, profileID *uint) (model.Account, error) {
+	account, err := repository.GetAccountByID(id)
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	proxyURL = strings.TrimSpace(proxyURL)
+	if err := upstream.ValidateProxyURL(proxyURL); err != nil {
+		return model.Account{}, err
+	}
+	if profileID != nil {
+		if _, err := repository.GetBrowserProfileByID(*profileID); err != nil {
+			return model.Account{}, err
+		}
+	}
+
+	account.ProxyURL = proxyURL
+	account.ProfileID = profileID
+	if err := repository.SaveAccount(account); err != nil {
+		return model.Account{}, err
+	}
+	return *account, nil
+}
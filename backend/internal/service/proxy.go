@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
+
+	"go.uber.org/zap"
+)
+
+// proxyChallengeProbeSize 为检测 WAF 挑战页时最多读取的 HTML 响应体大小。
+const proxyChallengeProbeSize = 64 << 10
+
+// ProxyAccount 为代理转发所需的上游信息，Session 仅在服务端使用。
+type ProxyAccount struct {
+	AccountID  uint
+	BaseURL    string
+	Credential upstream.Credential
+	WafCookie  string
+	Transport  http.RoundTripper
+}
+
+func GetProxyAccount(ctx context.Context, accountID uint) (ProxyAccount, error) {
+	account, cred, err := loadAccountCredential(accountID)
+	if err != nil {
+		return ProxyAccount{}, err
+	}
+	transport, err := upstream.Transport(cred.Identity.ProxyURL)
+	if err != nil {
+		return ProxyAccount{}, err
+	}
+	wafCookie, err := upstreamAPI.WafCookie(ctx, cred.Identity)
+	if err != nil {
+		return ProxyAccount{}, err
+	}
+
+	return ProxyAccount{
+		AccountID:  account.ID,
+		BaseURL:    upstreamAPI.BaseURL(),
+		Credential: cred,
+		WafCookie:  wafCookie,
+		Transport:  &proxyTransport{base: transport, accountID: account.ID, cred: cred},
+	}, nil
+}
+
+// loadAccountCredential 读取启用中的账号并组装携带 new-api-user 的登录态，
+// 令牌、日志等控制台接口均要求该请求头。
+func loadAccountCredential(accountID uint) (*model.Account, upstream.Credential, error) {
+	account, err := repository.GetAccountByID(accountID)
+	if err != nil {
+		return nil, upstream.Credential{}, err
+	}
+	if account.Status != 1 {
+		return nil, upstream.Credential{}, ErrAccountDisabled
+	}
+
+	if extractSessionValue(account.Session) == "" {
+		return nil, upstream.Credential{}, fmt.Errorf("%w: session 为空", ErrInvalidSession)
+	}
+
+	userID := account.UserID
+	if userID <= 0 {
+		info, err := ParseSession(account.Session)
+		if err != nil {
+			return nil, upstream.Credential{}, fmt.Errorf("%w: %v", ErrInvalidSession, err)
+		}
+		userID = info.UserID
+	}
+	return account, accountCredential(account, account.Session, userID), nil
+}
+
+// proxyTransport 在上游返回 WAF 挑战页时求解并刷新 WAF Cookie 后重试一次，
+// 与 upstream.Client 的重试逻辑一致，调用方不会收到挑战页。
+type proxyTransport struct {
+	base      http.RoundTripper
+	accountID uint
+	cred      upstream.Credential
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 请求体需要在重试时重放，入口已用 MaxBytesReader 限制大小。
+	var payload []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+		req.Body = io.NopCloser(bytes.NewReader(payload))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(payload)), nil
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if challenged, err := probeWafChallenge(resp); err != nil || !challenged {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	zap.L().Info("代理命中 WAF 挑战页，刷新 WAF Cookie 后重试", zap.Uint("account_id", t.accountID))
+	upstreamAPI.InvalidateWafCookie(t.cred.Identity)
+	wafCookie, err := upstreamAPI.WafCookie(req.Context(), t.cred.Identity)
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if payload != nil {
+		retry.Body, _ = req.GetBody()
+	}
+	t.cred.Apply(retry.Header, wafCookie)
+
+	resp, err = t.base.RoundTrip(retry)
+	if err != nil {
+		return nil, err
+	}
+	if challenged, err := probeWafChallenge(resp); err != nil || !challenged {
+		return resp, err
+	}
+	resp.Body.Close()
+	upstreamAPI.InvalidateWafCookie(t.cred.Identity)
+	return nil, &upstream.ChallengeError{Err: errors.New("刷新 Cookie 后仍返回挑战页")}
+}
+
+// probeWafChallenge 读取 HTML 响应体的开头判断是否为 WAF 挑战页，并把已读部分放回响应体。
+// 流式响应不是 HTML，不会被读取。
+func probeWafChallenge(resp *http.Response) (bool, error) {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return false, nil
+	}
+	probe, err := io.ReadAll(io.LimitReader(resp.Body, proxyChallengeProbeSize))
+	if err != nil {
+		resp.Body.Close()
+		return false, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(probe), resp.Body), resp.Body}
+	return upstream.IsWafChallenge(probe), nil
+}
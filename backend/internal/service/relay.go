@@ -0,0 +1,286 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	relayKeyPrefix      = "ar-"
+	relayKeyRandomBytes = 24
+	maxRelayWeight      = 100
+)
+
+var (
+	ErrInvalidRelayKey     = errors.New("API Key 无效或已停用")
+	ErrInvalidRelayChannel = errors.New("中转通道参数无效")
+)
+
+// RelayKeyCreated 为新建的 Key，完整 Key 仅在创建时返回一次。
+type RelayKeyCreated struct {
+	model.RelayKey
+	Key string `json:"key"`
+}
+
+// RelayChannelInfo 为中转通道及其运行状态，Key 已脱敏。
+type RelayChannelInfo struct {
+	model.RelayChannel
+	Key           string           `json:"key"`
+	Username      string           `json:"username"`
+	Failures      int              `json:"failures"`
+	CooldownUntil *carbon.DateTime `json:"cooldown_until" swaggertype:"string" format:"date-time"`
+}
+
+type RelayChannelParams struct {
+	AccountID uint
+	Name      string
+	Key       string
+	Weight    int
+}
+
+type RelayStatItem struct {
+	repository.RelayStat
+	KeyName string `json:"key_name,omitempty"`
+}
+
+func ListRelayKeys() ([]model.RelayKey, error) {
+	return repository.ListRelayKeys()
+}
+
+func CreateRelayKey(name string) (RelayKeyCreated, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return RelayKeyCreated{}, fmt.Errorf("%w: 名称不能为空且不超过 100 个字符", ErrInvalidRelayKey)
+	}
+	buf := make([]byte, relayKeyRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return RelayKeyCreated{}, err
+	}
+	raw := relayKeyPrefix + hex.EncodeToString(buf)
+	key := model.RelayKey{
+		Name:      name,
+		KeyHash:   hashRelayKey(raw),
+		KeyPrefix: raw[:len(relayKeyPrefix)+4],
+		Status:    1,
+	}
+	if err := repository.CreateRelayKey(&key); err != nil {
+		return RelayKeyCreated{}, err
+	}
+	return RelayKeyCreated{RelayKey: key, Key: raw}, nil
+}
+
+func UpdateRelayKey(id uint, name string, status int) (model.RelayKey, error) {
+	key, err := repository.GetRelayKeyByID(id)
+	if err != nil {
+		return model.RelayKey{}, err
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		key.Name = name
+	}
+	key.Status = status
+	if err := repository.SaveRelayKey(key); err != nil {
+		return model.RelayKey{}, err
+	}
+	return *key, nil
+}
+
+func DeleteRelayKey(id uint) error {
+	return repository.DeleteRelayKey(id)
+}
+
+// AuthenticateRelayKey 校验调用方携带的 Key，成功时更新最近使用时间。
+func AuthenticateRelayKey(raw string) (*model.RelayKey, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, relayKeyPrefix) {
+		return nil, ErrInvalidRelayKey
+	}
+	key, err := repository.GetRelayKeyByHash(hashRelayKey(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRelayKey
+		}
+		return nil, err
+	}
+	if key.Status != 1 {
+		return nil, ErrInvalidRelayKey
+	}
+	if err := repository.TouchRelayKey(key.ID); err != nil {
+		zap.L().Warn("更新 API Key 使用时间失败", zap.Uint("relay_key_id", key.ID), zap.Error(err))
+	}
+	return key, nil
+}
+
+func hashRelayKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func ListRelayChannels() ([]RelayChannelInfo, error) {
+	channels, err := repository.ListRelayChannels()
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := repository.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string, len(accounts))
+	for _, account := range accounts {
+		usernames[account.ID] = account.Username
+	}
+
+	items := make([]RelayChannelInfo, 0, len(channels))
+	for _, channel := range channels {
+		failures, cooldownUntil := relayChannelHealth(channel.ID)
+		items = append(items, RelayChannelInfo{
+			RelayChannel:  channel,
+			Key:           maskTokenKey(channel.Key),
+			Username:      usernames[channel.AccountID],
+			Failures:      failures,
+			CooldownUntil: cooldownUntil,
+		})
+	}
+	return items, nil
+}
+
+// CreateRelayChannel 将账号的上游令牌加入中转池；未提供 Key 时在上游新建一个不限额令牌。
+func CreateRelayChannel(params RelayChannelParams) (model.RelayChannel, error) {
+	weight, err := normalizeRelayWeight(params.Weight)
+	if err != nil {
+		return model.RelayChannel{}, err
+	}
+	account, err := repository.GetAccountByID(params.AccountID)
+	if err != nil {
+		return model.RelayChannel{}, err
+	}
+
+	channel := model.RelayChannel{
+		AccountID: account.ID,
+		Name:      strings.TrimSpace(params.Name),
+		Key:       strings.TrimSpace(params.Key),
+		Weight:    weight,
+		Status:    1,
+	}
+	if channel.Name == "" {
+		channel.Name = account.Username
+	}
+	if channel.Key == "" {
+		token, err := CreateAccountToken(account.ID, CreateTokenParams{Name: "relay"})
+		if err != nil {
+			return model.RelayChannel{}, fmt.Errorf("创建上游令牌失败: %w", err)
+		}
+		channel.Key = token.Key
+		channel.TokenID = token.ID
+	} else if !strings.HasPrefix(channel.Key, tokenKeyPrefix) {
+		channel.Key = tokenKeyPrefix + channel.Key
+	}
+
+	if err := repository.CreateRelayChannel(&channel); err != nil {
+		return model.RelayChannel{}, err
+	}
+	return channel, nil
+}
+
+// UpdateRelayChannel 更新名称、权重与状态，重新启用时清除失败记录。
+func UpdateRelayChannel(id uint, name string, weight, status int) (model.RelayChannel, error) {
+	weight, err := normalizeRelayWeight(weight)
+	if err != nil {
+		return model.RelayChannel{}, err
+	}
+	if status != 0 && status != 1 {
+		return model.RelayChannel{}, fmt.Errorf("%w: 状态无效", ErrInvalidRelayChannel)
+	}
+	channel, err := repository.GetRelayChannelByID(id)
+	if err != nil {
+		return model.RelayChannel{}, err
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		channel.Name = name
+	}
+	channel.Weight = weight
+	channel.Status = status
+	if status == 1 {
+		channel.LastError = ""
+		resetRelayChannelHealth(channel.ID)
+	}
+	if err := repository.SaveRelayChannel(channel); err != nil {
+		return model.RelayChannel{}, err
+	}
+	return *channel, nil
+}
+
+// DeleteRelayChannel 仅将令牌移出中转池，不会删除上游令牌。
+func DeleteRelayChannel(id uint) error {
+	resetRelayChannelHealth(id)
+	return repository.DeleteRelayChannel(id)
+}
+
+func normalizeRelayWeight(weight int) (int, error) {
+	if weight == 0 {
+		return 1, nil
+	}
+	if weight < 0 || weight > maxRelayWeight {
+		return 0, fmt.Errorf("%w: 权重需为 1~%d", ErrInvalidRelayChannel, maxRelayWeight)
+	}
+	return weight, nil
+}
+
+// RelayStats 返回按日期与 groupBy（key / account / model）聚合的中转用量，未指定日期时默认最近 30 天。
+func RelayStats(startDate, endDate, groupBy string) ([]RelayStatItem, error) {
+	switch groupBy {
+	case "":
+		groupBy = "key"
+	case "key", "account", "model":
+	default:
+		return nil, fmt.Errorf("%w: group_by 仅支持 key、account、model", ErrInvalidUsageQuery)
+	}
+	if startDate == "" && endDate == "" {
+		startDate = carbon.Now().SubDays(usageInitialDays).ToDateString()
+	}
+	if err := validateUsageFilter(UsageFilter{StartDate: startDate, EndDate: endDate}); err != nil {
+		return nil, err
+	}
+
+	stats, err := repository.SumRelayUsage(startDate, endDate, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string)
+	if groupBy == "key" {
+		keys, err := repository.ListRelayKeys()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			names[key.ID] = key.Name
+		}
+	}
+
+	items := make([]RelayStatItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, RelayStatItem{RelayStat: stat, KeyName: names[stat.RelayKeyID]})
+	}
+	return items, nil
+}
+
+// RecordRelayLog 保存一次中转请求的记录，失败只记录日志，不影响响应。
+func RecordRelayLog(log model.RelayLog) {
+	log.LogDate = carbon.Now().ToDateString()
+	log.Model = truncateMessage(log.Model, 100)
+	log.Path = truncateMessage(log.Path, 255)
+	if err := repository.CreateRelayLog(&log); err != nil {
+		zap.L().Error("保存中转记录失败", zap.Uint("relay_key_id", log.RelayKeyID), zap.Error(err))
+	}
+}
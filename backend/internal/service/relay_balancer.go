@@ -0,0 +1,355 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	RelayStrategyRoundRobin = "round_robin"
+	RelayStrategyBalance    = "balance"
+
+	// relayFailureThreshold 为连续失败多少次后进入冷却。
+	relayFailureThreshold = 3
+	// relayCooldownMinutes 为通道冷却的分钟数。
+	relayCooldownMinutes = 5
+	// relayBalanceScale 为余额加权时的精度，即按 0.01 额度取整后抽取。
+	relayBalanceScale = 2
+	// relayUsageBufferLimit 为非流式响应解析用量时最多缓存的响应体大小。
+	relayUsageBufferLimit = 1 << 20
+)
+
+var ErrNoRelayChannel = errors.New("没有可用的中转通道")
+
+// relayMinBalanceWeight 为余额加权的最小权重，余额不足的账号仍有少量机会被选中。
+var relayMinBalanceWeight = decimal.New(1, -relayBalanceScale)
+
+// RelayTarget 为一次中转选中的上游令牌。
+type RelayTarget struct {
+	ChannelID uint
+	AccountID uint
+	BaseURL   string
+	Key       string
+	Transport http.RoundTripper
+}
+
+type relayHealth struct {
+	failures      int
+	cooldownUntil *carbon.Carbon
+}
+
+var (
+	relayMu            sync.Mutex
+	relayHealthByID    = make(map[uint]*relayHealth)
+	relayCurrentWeight = make(map[uint]int)
+)
+
+type relayCandidate struct {
+	channel model.RelayChannel
+	account model.Account
+}
+
+// PickRelayTarget 按 relay.strategy 从可用通道中选择一个，跳过 exclude 中的通道。
+// 停用的通道与账号、余额低于 relay.min_balance 的账号以及冷却中的通道不参与选择。
+func PickRelayTarget(exclude map[uint]bool) (RelayTarget, error) {
+	candidates, err := relayCandidates(exclude)
+	if err != nil {
+		return RelayTarget{}, err
+	}
+	if len(candidates) == 0 {
+		return RelayTarget{}, ErrNoRelayChannel
+	}
+
+	var picked relayCandidate
+	if GetConfig("relay.strategy") == RelayStrategyBalance {
+		picked = pickByBalance(candidates)
+	} else {
+		picked = pickRoundRobin(candidates)
+	}
+
+	transport, err := upstream.Transport(picked.account.ProxyURL)
+	if err != nil {
+		return RelayTarget{}, err
+	}
+	return RelayTarget{
+		ChannelID: picked.channel.ID,
+		AccountID: picked.account.ID,
+		BaseURL:   upstreamAPI.BaseURL(),
+		Key:       picked.channel.Key,
+		Transport: transport,
+	}, nil
+}
+
+func relayCandidates(exclude map[uint]bool) ([]relayCandidate, error) {
+	channels, err := repository.ListRelayChannels()
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := repository.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	accountByID := make(map[uint]model.Account, len(accounts))
+	for _, account := range accounts {
+		accountByID[account.ID] = account
+	}
+	minBalance, err := decimal.NewFromString(strings.TrimSpace(GetConfig("relay.min_balance")))
+	if err != nil {
+		minBalance = decimal.Zero
+	}
+
+	now := carbon.Now()
+	relayMu.Lock()
+	defer relayMu.Unlock()
+	candidates := make([]relayCandidate, 0, len(channels))
+	for _, channel := range channels {
+		if channel.Status != 1 || exclude[channel.ID] {
+			continue
+		}
+		account, ok := accountByID[channel.AccountID]
+		if !ok || account.Status != 1 {
+			continue
+		}
+		if minBalance.IsPositive() && account.Balance.LessThan(minBalance) {
+			continue
+		}
+		if health := relayHealthByID[channel.ID]; health != nil && health.coolingDown(now) {
+			continue
+		}
+		candidates = append(candidates, relayCandidate{channel: channel, account: account})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].channel.ID < candidates[j].channel.ID
+	})
+	return candidates, nil
+}
+
+// pickRoundRobin 使用平滑加权轮询，权重为通道的 Weight。
+func pickRoundRobin(candidates []relayCandidate) relayCandidate {
+	relayMu.Lock()
+	defer relayMu.Unlock()
+	total := 0
+	best := -1
+	for i, candidate := range candidates {
+		weight := max(candidate.channel.Weight, 1)
+		total += weight
+		relayCurrentWeight[candidate.channel.ID] += weight
+		if best < 0 || relayCurrentWeight[candidate.channel.ID] > relayCurrentWeight[candidates[best].channel.ID] {
+			best = i
+		}
+	}
+	relayCurrentWeight[candidates[best].channel.ID] -= total
+	return candidates[best]
+}
+
+// pickByBalance 按账号余额加权随机选择，余额越多被选中的概率越高。
+func pickByBalance(candidates []relayCandidate) relayCandidate {
+	weights, total := relayBalanceWeights(candidates)
+	// 权重按 relayBalanceScale 精度换算为整数额度后抽取，避免使用浮点数。
+	r := decimal.New(rand.Int64N(total.Shift(relayBalanceScale).IntPart()), -relayBalanceScale)
+	for i, weight := range weights {
+		if r.LessThan(weight) {
+			return candidates[i]
+		}
+		r = r.Sub(weight)
+	}
+	return candidates[len(candidates)-1]
+}
+
+// relayBalanceWeights 返回各候选的余额权重及其总和，权重不低于 relayMinBalanceWeight。
+func relayBalanceWeights(candidates []relayCandidate) ([]decimal.Decimal, decimal.Decimal) {
+	weights := make([]decimal.Decimal, len(candidates))
+	total := decimal.Zero
+	for i, candidate := range candidates {
+		weights[i] = decimal.Max(candidate.account.Balance.Truncate(relayBalanceScale), relayMinBalanceWeight)
+		total = total.Add(weights[i])
+	}
+	return weights, total
+}
+
+// IsRelayRetryable 判断上游响应是否应换一个通道重试。
+func IsRelayRetryable(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden ||
+		statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// ReportRelayResult 根据上游结果更新通道状态：鉴权失败直接停用，其余失败累计到阈值后冷却。
+func ReportRelayResult(target RelayTarget, statusCode int, err error) {
+	if err == nil && !IsRelayRetryable(statusCode) {
+		resetRelayChannelHealth(target.ChannelID)
+		now := carbon.DateTime{Carbon: carbon.Now()}
+		if err := repository.UpdateRelayChannelColumns(target.ChannelID, map[string]interface{}{"last_used_at": now}); err != nil {
+			zap.L().Warn("更新中转通道失败", zap.Uint("channel_id", target.ChannelID), zap.Error(err))
+		}
+		return
+	}
+
+	reason := http.StatusText(statusCode)
+	if err != nil {
+		reason = err.Error()
+	}
+	values := map[string]interface{}{"last_error": truncateMessage(reason, 255)}
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		values["status"] = 2
+		zap.L().Warn("中转通道鉴权失败，已自动停用", zap.Uint("channel_id", target.ChannelID), zap.Int("status", statusCode))
+	} else {
+		relayMu.Lock()
+		health := relayHealthByID[target.ChannelID]
+		if health == nil {
+			health = &relayHealth{}
+			relayHealthByID[target.ChannelID] = health
+		}
+		health.failures++
+		if health.failures >= relayFailureThreshold {
+			health.failures = 0
+			health.cooldownUntil = carbon.Now().AddMinutes(relayCooldownMinutes)
+			zap.L().Warn("中转通道连续失败，进入冷却", zap.Uint("channel_id", target.ChannelID), zap.String("reason", reason))
+		}
+		relayMu.Unlock()
+	}
+	if err := repository.UpdateRelayChannelColumns(target.ChannelID, values); err != nil {
+		zap.L().Warn("更新中转通道失败", zap.Uint("channel_id", target.ChannelID), zap.Error(err))
+	}
+}
+
+func relayChannelHealth(channelID uint) (int, *carbon.DateTime) {
+	relayMu.Lock()
+	defer relayMu.Unlock()
+	health := relayHealthByID[channelID]
+	if health == nil {
+		return 0, nil
+	}
+	if health.coolingDown(carbon.Now()) {
+		until := carbon.DateTime{Carbon: health.cooldownUntil}
+		return health.failures, &until
+	}
+	return health.failures, nil
+}
+
+func (h *relayHealth) coolingDown(now *carbon.Carbon) bool {
+	return h.cooldownUntil != nil && now.Lt(h.cooldownUntil)
+}
+
+func resetRelayChannelHealth(channelID uint) {
+	relayMu.Lock()
+	defer relayMu.Unlock()
+	delete(relayHealthByID, channelID)
+}
+
+// InspectRelayRequest 从请求体中读取模型名与是否流式。
+func InspectRelayRequest(body []byte) (string, bool) {
+	var req struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", false
+	}
+	return req.Model, req.Stream
+}
+
+type relayUsage struct {
+	PromptTokens             int64 `json:"prompt_tokens"`
+	CompletionTokens         int64 `json:"completion_tokens"`
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+type relayUsageMessage struct {
+	Usage *relayUsage `json:"usage"`
+}
+
+// RelayUsageCollector 旁路读取中转响应，兼容 OpenAI 与 Anthropic 的 usage 字段，
+// 流式响应逐行解析 SSE，非流式响应在结束后整体解析。
+type RelayUsageCollector struct {
+	stream     bool
+	buf        bytes.Buffer
+	overflow   bool
+	prompt     int64
+	completion int64
+}
+
+func NewRelayUsageCollector(stream bool) *RelayUsageCollector {
+	return &RelayUsageCollector{stream: stream}
+}
+
+func (u *RelayUsageCollector) Write(p []byte) (int, error) {
+	if !u.stream {
+		if !u.overflow && u.buf.Len()+len(p) <= relayUsageBufferLimit {
+			u.buf.Write(p)
+		} else {
+			u.overflow = true
+		}
+		return len(p), nil
+	}
+
+	u.buf.Write(p)
+	for {
+		line, err := u.buf.ReadBytes('\n')
+		if err != nil {
+			// 不完整的行放回缓冲区等待后续数据。
+			rest := append([]byte(nil), line...)
+			u.buf.Reset()
+			u.buf.Write(rest)
+			break
+		}
+		u.parseEvent(line)
+	}
+	return len(p), nil
+}
+
+func (u *RelayUsageCollector) parseEvent(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok || !bytes.Contains(data, []byte(`"usage"`)) {
+		return
+	}
+	u.parse(bytes.TrimSpace(data))
+}
+
+func (u *RelayUsageCollector) parse(data []byte) {
+	var payload struct {
+		Usage   *relayUsage        `json:"usage"`
+		Message *relayUsageMessage `json:"message"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+	usages := []*relayUsage{payload.Usage}
+	if payload.Message != nil {
+		usages = append(usages, payload.Message.Usage)
+	}
+	for _, usage := range usages {
+		if usage == nil {
+			continue
+		}
+		prompt := usage.PromptTokens + usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+		completion := usage.CompletionTokens + usage.OutputTokens
+		u.prompt = max(u.prompt, prompt)
+		u.completion = max(u.completion, completion)
+	}
+}
+
+// Result 返回解析到的输入与输出 token 数。
+func (u *RelayUsageCollector) Result() (int64, int64) {
+	if !u.stream && !u.overflow && u.buf.Len() > 0 {
+		u.parse(u.buf.Bytes())
+		u.buf.Reset()
+	}
+	return u.prompt, u.completion
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/shopspring/decimal"
+)
+
+// testRelayChannel 描述一个测试通道及其所属账号。
+type testRelayChannel struct {
+	weight        int
+	channelStatus int
+	accountStatus int
+	balance       string
+}
+
+// setupRelayChannels 按顺序创建账号与通道并清空进程内的通道健康状态，返回通道 ID。
+func setupRelayChannels(t *testing.T, relayConfig map[string]string, channels ...testRelayChannel) []uint {
+	t.Helper()
+	setupTestDB(t)
+	relayMu.Lock()
+	clear(relayHealthByID)
+	clear(relayCurrentWeight)
+	relayMu.Unlock()
+	if len(relayConfig) > 0 {
+		if err := UpdateConfigs("relay", relayConfig, ConfigActor{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := make([]uint, 0, len(channels))
+	for _, c := range channels {
+		account := model.Account{Username: "relay", Balance: decimal.RequireFromString(c.balance)}
+		if err := repository.CreateAccount(&account); err != nil {
+			t.Fatal(err)
+		}
+		channel := model.RelayChannel{AccountID: account.ID, Key: "sk-test", Weight: c.weight}
+		if err := repository.CreateRelayChannel(&channel); err != nil {
+			t.Fatal(err)
+		}
+		// 状态列带有默认值，零值不会写入，创建后再更新。
+		if err := repository.DB.Model(&account).Update("status", c.accountStatus).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := repository.UpdateRelayChannelColumns(channel.ID, map[string]interface{}{"status": c.channelStatus}); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, channel.ID)
+	}
+	return ids
+}
+
+// pickRelayChannels 连续选择 n 次，返回选中的通道 ID。
+func pickRelayChannels(t *testing.T, n int, exclude map[uint]bool) ([]uint, error) {
+	t.Helper()
+	var picked []uint
+	for range n {
+		target, err := PickRelayTarget(exclude)
+		if err != nil {
+			return picked, err
+		}
+		picked = append(picked, target.ChannelID)
+	}
+	return picked, nil
+}
+
+func TestPickRelayTarget(t *testing.T) {
+	available := testRelayChannel{weight: 1, channelStatus: 1, accountStatus: 1, balance: "10"}
+	tests := []struct {
+		name     string
+		config   map[string]string
+		channels []testRelayChannel
+		// exclude 与 want 为通道序号（从 0 开始）。
+		exclude []int
+		want    []int
+		wantErr error
+	}{
+		{
+			name:     "平滑加权轮询",
+			channels: []testRelayChannel{{3, 1, 1, "10"}, available},
+			want:     []int{0, 0, 1, 0, 0, 0, 1, 0},
+		},
+		{
+			name:     "跳过停用的通道与账号",
+			channels: []testRelayChannel{{1, 2, 1, "10"}, {1, 1, 2, "10"}, available},
+			want:     []int{2, 2},
+		},
+		{
+			name:     "跳过余额不足的账号",
+			config:   map[string]string{"relay.min_balance": "5"},
+			channels: []testRelayChannel{{1, 1, 1, "4.99"}, available},
+			want:     []int{1, 1},
+		},
+		{
+			name:     "故障转移时排除已尝试的通道",
+			channels: []testRelayChannel{available, available},
+			exclude:  []int{0},
+			want:     []int{1, 1},
+		},
+		{
+			name:     "全部排除后无可用通道",
+			channels: []testRelayChannel{available, available},
+			exclude:  []int{0, 1},
+			wantErr:  ErrNoRelayChannel,
+		},
+		{
+			name:     "按余额选择时只在可用通道中抽取",
+			config:   map[string]string{"relay.strategy": RelayStrategyBalance},
+			channels: []testRelayChannel{{1, 2, 1, "1000"}, {1, 1, 1, "0"}},
+			want:     []int{1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := setupRelayChannels(t, tt.config, tt.channels...)
+			exclude := make(map[uint]bool)
+			for _, i := range tt.exclude {
+				exclude[ids[i]] = true
+			}
+			picked, err := pickRelayChannels(t, max(len(tt.want), 1), exclude)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			want := make([]uint, len(tt.want))
+			for i, index := range tt.want {
+				want[i] = ids[index]
+			}
+			if !slices.Equal(picked, want) {
+				t.Fatalf("选中的通道 = %v, want %v", picked, want)
+			}
+		})
+	}
+}
+
+func TestRelayBalanceWeights(t *testing.T) {
+	tests := []struct {
+		balances []string
+		want     []string
+		total    string
+	}{
+		{[]string{"100", "300"}, []string{"100", "300"}, "400"},
+		{[]string{"0", "-5", "12.34"}, []string{"0.01", "0.01", "12.34"}, "12.36"},
+		{[]string{"0.019"}, []string{"0.01"}, "0.01"},
+	}
+	for _, tt := range tests {
+		candidates := make([]relayCandidate, len(tt.balances))
+		for i, balance := range tt.balances {
+			candidates[i].account.Balance = decimal.RequireFromString(balance)
+		}
+		weights, total := relayBalanceWeights(candidates)
+		for i, weight := range weights {
+			if !weight.Equal(decimal.RequireFromString(tt.want[i])) {
+				t.Fatalf("余额 %v 的权重 = %v, want %v", tt.balances, weights, tt.want)
+			}
+		}
+		if !total.Equal(decimal.RequireFromString(tt.total)) {
+			t.Fatalf("余额 %v 的总权重 = %v, want %s", tt.balances, total, tt.total)
+		}
+	}
+}
+
+func TestPickByBalanceDistribution(t *testing.T) {
+	candidates := []relayCandidate{
+		{channel: model.RelayChannel{ID: 1}, account: model.Account{Balance: decimal.RequireFromString("100")}},
+		{channel: model.RelayChannel{ID: 2}, account: model.Account{Balance: decimal.RequireFromString("300")}},
+		{channel: model.RelayChannel{ID: 3}, account: model.Account{Balance: decimal.Zero}},
+	}
+	const draws = 20000
+	counts := make(map[uint]int)
+	for range draws {
+		counts[pickByBalance(candidates).channel.ID]++
+	}
+	// 期望约为 1/4 与 3/4，容差远大于抽样误差；余额为 0 的通道仍按最小权重参与。
+	if share := counts[1] * 100 / draws; share < 22 || share > 28 {
+		t.Fatalf("余额 100 的通道占比 %d%%，counts=%v", share, counts)
+	}
+	if share := counts[2] * 100 / draws; share < 72 || share > 78 {
+		t.Fatalf("余额 300 的通道占比 %d%%，counts=%v", share, counts)
+	}
+	if counts[3] > draws/100 {
+		t.Fatalf("余额为 0 的通道被选中过多：counts=%v", counts)
+	}
+}
+
+func TestReportRelayResult(t *testing.T) {
+	type report struct {
+		status int
+		err    error
+	}
+	fail := report{status: http.StatusBadGateway}
+	tests := []struct {
+		name         string
+		reports      []report
+		wantStatus   int
+		wantFailures int
+		wantCooldown bool
+	}{
+		{"成功后更新最近使用时间", []report{{status: http.StatusOK}}, 1, 0, false},
+		{"鉴权失败直接停用", []report{{status: http.StatusUnauthorized}}, 2, 0, false},
+		{"禁止访问直接停用", []report{{status: http.StatusForbidden}}, 2, 0, false},
+		{"未达阈值仍可使用", []report{fail, {status: http.StatusTooManyRequests}}, 1, 2, false},
+		{"连接错误计入失败", []report{fail, fail, {err: errors.New("dial tcp: connection refused")}}, 1, 0, true},
+		{"连续失败进入冷却", []report{fail, fail, fail}, 1, 0, true},
+		{"成功后清零失败次数", []report{fail, fail, {status: http.StatusOK}, fail}, 1, 1, false},
+		{"客户端错误不计入失败", []report{fail, {status: http.StatusBadRequest}}, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := setupRelayChannels(t, nil, testRelayChannel{1, 1, 1, "10"}, testRelayChannel{1, 1, 1, "10"})
+			now := carbon.Parse("2026-03-01 08:00:00")
+			carbon.SetTestNow(now)
+			target := RelayTarget{ChannelID: ids[0]}
+			for _, r := range tt.reports {
+				ReportRelayResult(target, r.status, r.err)
+			}
+
+			channel, err := repository.GetRelayChannelByID(ids[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if channel.Status != tt.wantStatus {
+				t.Fatalf("通道状态 = %d, want %d", channel.Status, tt.wantStatus)
+			}
+			last := tt.reports[len(tt.reports)-1]
+			if last.err == nil && !IsRelayRetryable(last.status) {
+				if channel.LastUsedAt == nil || channel.LastUsedAt.Timestamp() != now.Timestamp() {
+					t.Fatalf("成功后应更新 last_used_at: %v", channel.LastUsedAt)
+				}
+			} else if channel.LastError == "" {
+				t.Fatal("失败后应记录 last_error")
+			}
+
+			failures, until := relayChannelHealth(ids[0])
+			if failures != tt.wantFailures || (until != nil) != tt.wantCooldown {
+				t.Fatalf("健康状态 = (%d, %v), want (%d, cooldown=%v)", failures, until, tt.wantFailures, tt.wantCooldown)
+			}
+			if tt.wantCooldown && until.Timestamp() != now.AddMinutes(relayCooldownMinutes).Timestamp() {
+				t.Fatalf("冷却截止时间 = %v", until)
+			}
+
+			// 停用或冷却中的通道不再参与选择，冷却结束后恢复。
+			unavailable := tt.wantStatus != 1 || tt.wantCooldown
+			picked, err := pickRelayChannels(t, 2, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if slices.Contains(picked, ids[0]) == unavailable {
+				t.Fatalf("选中的通道 = %v，通道 %d 不可用 = %v", picked, ids[0], unavailable)
+			}
+			if tt.wantCooldown {
+				carbon.SetTestNow(now.AddMinutes(relayCooldownMinutes))
+				if picked, _ := pickRelayChannels(t, 2, nil); !slices.Contains(picked, ids[0]) {
+					t.Fatalf("冷却结束后应恢复选择: %v", picked)
+				}
+			}
+		})
+	}
+}
+
+func TestRelayUsageCollector(t *testing.T) {
+	sse := func(events ...string) string {
+		return "data: " + strings.Join(events, "\n\ndata: ") + "\n\n"
+	}
+	tests := []struct {
+		name           string
+		stream         bool
+		chunks         []string
+		wantPrompt     int64
+		wantCompletion int64
+	}{
+		{
+			name:           "OpenAI 非流式",
+			chunks:         []string{`{"id":"chatcmpl-1","usage":{"prompt_tokens":12,`, `"completion_tokens":34,"total_tokens":46}}`},
+			wantPrompt:     12,
+			wantCompletion: 34,
+		},
+		{
+			name:           "Anthropic 非流式含缓存",
+			chunks:         []string{`{"type":"message","usage":{"input_tokens":10,"cache_creation_input_tokens":3,"cache_read_input_tokens":5,"output_tokens":7}}`},
+			wantPrompt:     18,
+			wantCompletion: 7,
+		},
+		{
+			name:   "OpenAI 流式的用量在最后一个块且跨写入",
+			stream: true,
+			chunks: []string{
+				sse(`{"choices":[{"delta":{"content":"hi"}}]}`) + `data: {"choices":[],"usage":{"prompt_tok`,
+				`ens":20,"completion_tokens":5}}` + "\n\ndata: [DONE]\n\n",
+			},
+			wantPrompt:     20,
+			wantCompletion: 5,
+		},
+		{
+			name:   "Anthropic 流式取 message_start 与 message_delta 的最大值",
+			stream: true,
+			chunks: []string{
+				"event: message_start\n" + sse(`{"type":"message_start","message":{"usage":{"input_tokens":25,"output_tokens":1}}}`),
+				"event: message_delta\r\n" + sse(`{"type":"message_delta","usage":{"output_tokens":15}}`),
+			},
+			wantPrompt:     25,
+			wantCompletion: 15,
+		},
+		{
+			name:   "流式未以换行结束的用量不计入",
+			stream: true,
+			chunks: []string{`data: {"usage":{"prompt_tokens":1,"completion_tokens":1}}`},
+		},
+		{
+			name:   "非 JSON 响应",
+			chunks: []string{"<html>usage</html>"},
+		},
+		{
+			name:   "超出缓存上限的非流式响应",
+			chunks: []string{`{"usage":{"prompt_tokens":1,"completion_tokens":1},"pad":"`, strings.Repeat("x", relayUsageBufferLimit), `"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewRelayUsageCollector(tt.stream)
+			for _, chunk := range tt.chunks {
+				if n, err := collector.Write([]byte(chunk)); n != len(chunk) || err != nil {
+					t.Fatalf("Write = (%d, %v)", n, err)
+				}
+			}
+			prompt, completion := collector.Result()
+			if prompt != tt.wantPrompt || completion != tt.wantCompletion {
+				t.Fatalf("Result = (%d, %d), want (%d, %d)", prompt, completion, tt.wantPrompt, tt.wantCompletion)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Scope 为当前用户可见的账号与定时任务范围。
+type Scope = repository.OwnerScope
+
+var ErrInvalidTaskAccounts = errors.New("任务包含不存在或无权访问的账号")
+
+// ResolveScope 计算用户的可见范围：管理员不受限制，其他用户可见自己及同团队成员拥有的数据。
+func ResolveScope(userID uint) (Scope, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return Scope{}, err
+	}
+	if user.Role == model.RoleAdmin {
+		return Scope{}, nil
+	}
+	scope := Scope{Restricted: true, OwnerIDs: []uint{user.ID}}
+	if user.TeamID != nil {
+		members, err := repository.ListUserIDsByTeam(*user.TeamID)
+		if err != nil {
+			return Scope{}, err
+		}
+		scope.OwnerIDs = members
+	}
+	return scope, nil
+}
+
+// CheckAccountAccess 校验账号均在范围内，不可见的账号按不存在处理。
+func CheckAccountAccess(scope Scope, accountIDs ...uint) error {
+	for _, id := range accountIDs {
+		account, err := repository.GetAccountByID(id)
+		if err != nil {
+			return err
+		}
+		if !scope.Allows(account.OwnerID) {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
+// CheckCronTaskAccess 校验定时任务在范围内，不可见的任务按不存在处理。
+func CheckCronTaskAccess(scope Scope, taskID uint) error {
+	task, err := repository.GetCronTaskByID(taskID)
+	if err != nil {
+		return err
+	}
+	if !scope.Allows(task.OwnerID) {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// checkTaskAccounts 校验任务中的账号均在范围内。
+func checkTaskAccounts(scope Scope, rawAccountIDs string) error {
+	ids, err := parseAccountIDs(rawAccountIDs)
+	if err != nil {
+		return ErrInvalidTaskAccounts
+	}
+	if err := CheckAccountAccess(scope, ids...); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidTaskAccounts
+		}
+		return err
+	}
+	return nil
+}
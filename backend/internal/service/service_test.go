@@ -0,0 +1,52 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// setupTestDB 以临时 SQLite 数据库初始化配置、仓储与配置缓存，测试结束后关闭连接并恢复时钟。
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "app.db")},
+		JWT:      config.JWTConfig{Secret: "service-test-secret", Expire: time.Hour, RefreshExpire: 24 * time.Hour},
+		AES:      config.AESConfig{Key: "0123456789abcdef0123456789abcdef"},
+		Admin:    config.AdminConfig{Username: "admin", Password: "service-test-password"},
+		Proxy:    config.ProxyConfig{DialTimeout: 5 * time.Second, ResponseHeaderTimeout: 5 * time.Second, MaxBodySize: 10 << 20},
+	}
+	config.Set(cfg)
+	if err := repository.Init(cfg.Database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		carbon.ClearTestNow()
+		if sqlDB, err := repository.DB.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	if err := InitDefaultConfigs(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupTestAdmin 初始化测试数据库并创建默认管理员，返回该用户。
+func setupTestAdmin(t *testing.T) *model.User {
+	t.Helper()
+	setupTestDB(t)
+	if err := InitAdminUser(); err != nil {
+		t.Fatal(err)
+	}
+	user, err := repository.GetUserByUsername(config.Get().Admin.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
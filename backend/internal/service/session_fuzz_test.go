@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sessionFixtures 读取 testdata/session 下的 Session 样本，文件名（不含扩展名）为样本名。
+func sessionFixtures(tb testing.TB) map[string]string {
+	tb.Helper()
+	files, err := filepath.Glob(filepath.Join("testdata", "session", "*.txt"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	fixtures := make(map[string]string, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		fixtures[name] = strings.TrimSuffix(string(data), "\n")
+	}
+	if len(fixtures) == 0 {
+		tb.Fatal("testdata/session 下没有样本")
+	}
+	return fixtures
+}
+
+// sessionGobFixtures 返回样本中可解码的 gob 数据，作为 gob 与旧格式解析器的种子。
+func sessionGobFixtures(tb testing.TB) [][]byte {
+	tb.Helper()
+	var seeds [][]byte
+	for _, fixture := range sessionFixtures(tb) {
+		decoded, err := decodeSessionValue(extractSessionValue(fixture))
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(string(decoded), "|")
+		if len(parts) < 3 {
+			continue
+		}
+		if gobData, err := decodeSessionPart([]byte(parts[1])); err == nil {
+			seeds = append(seeds, gobData)
+		}
+	}
+	return seeds
+}
+
+func TestParseSessionGolden(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "session", "golden.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var golden map[string]SessionInfo
+	if err := json.Unmarshal(data, &golden); err != nil {
+		t.Fatal(err)
+	}
+	fixtures := sessionFixtures(t)
+	if len(golden) != len(fixtures) {
+		t.Fatalf("golden.json 有 %d 项，样本有 %d 个", len(golden), len(fixtures))
+	}
+	for name, fixture := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			want, ok := golden[name]
+			if !ok {
+				t.Fatalf("golden.json 缺少 %s", name)
+			}
+			got, err := ParseSession(fixture)
+			if err != nil {
+				t.Fatalf("ParseSession: %v", err)
+			}
+			if *got != want {
+				t.Fatalf("got %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+// 编码变体样本须互不相同且确实采用对应的编码，避免语料退化为同一输入。
+func TestSessionFixtureEncodings(t *testing.T) {
+	fixtures := sessionFixtures(t)
+	seen := make(map[string]string)
+	for name, fixture := range fixtures {
+		if other, ok := seen[fixture]; ok {
+			t.Fatalf("样本 %s 与 %s 相同", name, other)
+		}
+		seen[fixture] = name
+	}
+	tests := []struct {
+		name    string
+		enc     *base64.Encoding
+		escaped bool
+	}{
+		{"padded", base64.URLEncoding, false},
+		{"unpadded", base64.RawURLEncoding, false},
+		{"std_padded", base64.StdEncoding, false},
+		{"std_unpadded", base64.RawStdEncoding, false},
+		{"url_encoded", base64.StdEncoding, true},
+	}
+	for _, tt := range tests {
+		value := fixtures[tt.name]
+		if tt.escaped {
+			if !strings.Contains(value, "%") {
+				t.Fatalf("%s 应为 URL 编码", tt.name)
+			}
+			var err error
+			if value, err = url.QueryUnescape(value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := tt.enc.DecodeString(value); err != nil {
+			t.Fatalf("%s 不是预期的 Base64 编码: %v", tt.name, err)
+		}
+		// 标准字母表与 URL 字母表、有无填充的样本须各自无法被另一种编码解码。
+		for _, other := range []*base64.Encoding{base64.URLEncoding, base64.RawURLEncoding, base64.StdEncoding, base64.RawStdEncoding} {
+			if other != tt.enc {
+				if _, err := other.DecodeString(value); err == nil {
+					t.Fatalf("%s 也能按其他 Base64 编码解码，未覆盖对应变体", tt.name)
+				}
+			}
+		}
+	}
+}
+
+func TestParseSessionTooLong(t *testing.T) {
+	if _, err := ParseSession(strings.Repeat("a", maxSessionLength+1)); err != ErrSessionTooLong {
+		t.Fatalf("got %v, want ErrSessionTooLong", err)
+	}
+}
+
+func FuzzParseSession(f *testing.F) {
+	for _, fixture := range sessionFixtures(f) {
+		f.Add(fixture)
+	}
+	f.Add("")
+	f.Add("session=")
+	f.Add("fHx8")
+	f.Fuzz(func(t *testing.T, input string) {
+		info, err := ParseSession(input)
+		if err == nil && (info == nil || info.UserID == 0) {
+			t.Fatalf("解析成功但缺少用户 ID: %+v", info)
+		}
+	})
+}
+
+func FuzzDecodeSessionValue(f *testing.F) {
+	for _, fixture := range sessionFixtures(f) {
+		f.Add(extractSessionValue(fixture))
+	}
+	f.Add("%")
+	f.Add("\"'")
+	f.Add("a b=")
+	f.Fuzz(func(t *testing.T, input string) {
+		decoded, err := decodeSessionValue(input)
+		if err != nil && decoded != nil {
+			t.Fatalf("返回错误时不应返回数据: %q", decoded)
+		}
+	})
+}
+
+func FuzzParseCookieHeader(f *testing.F) {
+	for _, fixture := range sessionFixtures(f) {
+		f.Add(fixture)
+	}
+	f.Add("session")
+	f.Add(";;=;session==x")
+	f.Fuzz(func(t *testing.T, input string) {
+		for name, value := range parseCookieHeader(input) {
+			if name == "" || name != strings.TrimSpace(name) || value != strings.TrimSpace(value) {
+				t.Fatalf("cookie 未规范化: %q=%q", name, value)
+			}
+		}
+		if value := extractSessionValue(input); len(value) > len(input) {
+			t.Fatalf("session 值比输入更长: %q", value)
+		}
+	})
+}
+
+func FuzzParseSessionGob(f *testing.F) {
+	for _, seed := range sessionGobFixtures(f) {
+		f.Add(seed)
+	}
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := parseSessionGob(data)
+		if err == nil && (info == nil || info.UserID == 0) {
+			t.Fatalf("解析成功但缺少用户 ID: %+v", info)
+		}
+	})
+}
+
+func FuzzParseSessionLegacy(f *testing.F) {
+	for _, seed := range sessionGobFixtures(f) {
+		f.Add(seed)
+	}
+	f.Add([]byte("string\x0c"))
+	f.Add([]byte("int\x04\x04\x00\xfe"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := parseSessionLegacy(data)
+		if err == nil && (info == nil || info.UserID == 0) {
+			t.Fatalf("解析成功但缺少用户 ID: %+v", info)
+		}
+	})
+}
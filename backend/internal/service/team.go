@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+)
+
+var ErrInvalidTeam = errors.New("团队参数无效")
+
+func ListTeams() ([]model.Team, error) {
+	return repository.ListTeams()
+}
+
+func CreateTeam(name string) (model.Team, error) {
+	name, err := normalizeTeamName(name)
+	if err != nil {
+		return model.Team{}, err
+	}
+	team := model.Team{Name: name}
+	if err := repository.CreateTeam(&team); err != nil {
+		return model.Team{}, err
+	}
+	return team, nil
+}
+
+func UpdateTeam(id uint, name string) (model.Team, error) {
+	name, err := normalizeTeamName(name)
+	if err != nil {
+		return model.Team{}, err
+	}
+	team, err := repository.GetTeamByID(id)
+	if err != nil {
+		return model.Team{}, err
+	}
+	team.Name = name
+	if err := repository.SaveTeam(team); err != nil {
+		return model.Team{}, err
+	}
+	return *team, nil
+}
+
+// DeleteTeam 删除团队，成员保留各自的账号与任务，但不再互相可见。
+func DeleteTeam(id uint) error {
+	return repository.DeleteTeam(id)
+}
+
+func normalizeTeamName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 50 {
+		return "", fmt.Errorf("%w: 名称不能为空且不超过 50 个字符", ErrInvalidTeam)
+	}
+	return name, nil
+}
@@ -8,7 +8,9 @@ import (
 	"html"
 	"net/http"
 	neturl "net/url"
+	"slices"
 	"strings"
+	"sync"
 	"text/template"
 
 	"anyrouter-checkin/internal/repository"
@@ -18,13 +20,65 @@ import (
 
 var ErrNoSuccessfulCheckinLog = errors.New("暂无成功签到记录")
 
+var (
+	telegramMu   sync.Mutex
+	telegramHTTP *http.Client
+)
+
+func init() {
+	OnConfigChange(func(keys []string) {
+		if slices.Contains(keys, "telegram.proxy_url") {
+			resetTelegramClient()
+		}
+	})
+}
+
+// telegramClient 返回按 telegram.proxy_url 创建的客户端，复用连接，代理变更后重新创建。
+func telegramClient() (*http.Client, error) {
+	telegramMu.Lock()
+	defer telegramMu.Unlock()
+	if telegramHTTP != nil {
+		return telegramHTTP, nil
+	}
+
+	client := &http.Client{}
+	proxyURL := strings.TrimSpace(GetConfig("telegram.proxy_url"))
+	if proxyURL != "" {
+		proxy, err := neturl.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址无效")
+		}
+		client.Transport = &http.Transport{
+			Proxy: http.ProxyURL(proxy),
+		}
+	}
+	telegramHTTP = client
+	return client, nil
+}
+
+func resetTelegramClient() {
+	telegramMu.Lock()
+	defer telegramMu.Unlock()
+	if telegramHTTP != nil {
+		telegramHTTP.CloseIdleConnections()
+		telegramHTTP = nil
+	}
+}
+
 func SendTelegramMessage(message string) error {
+	return sendTelegramMessageTo("", message)
+}
+
+// sendTelegramMessageTo 发送到指定会话，chatID 为空时使用 telegram.chat_id。
+func sendTelegramMessageTo(chatID, message string) error {
 	if GetConfig("telegram.enabled") != "true" {
 		return nil
 	}
 
 	botToken := GetConfig("telegram.bot_token")
-	chatID := GetConfig("telegram.chat_id")
+	if chatID == "" {
+		chatID = GetConfig("telegram.chat_id")
+	}
 	if botToken == "" || chatID == "" {
 		return fmt.Errorf("telegram 配置不完整")
 	}
@@ -45,16 +99,9 @@ func SendTelegramMessage(message string) error {
 		return err
 	}
 
-	client := &http.Client{}
-	proxyURL := strings.TrimSpace(GetConfig("telegram.proxy_url"))
-	if proxyURL != "" {
-		proxy, err := neturl.Parse(proxyURL)
-		if err != nil {
-			return fmt.Errorf("代理地址无效")
-		}
-		client.Transport = &http.Transport{
-			Proxy: http.ProxyURL(proxy),
-		}
+	client, err := telegramClient()
+	if err != nil {
+		return err
 	}
 
 	resp, err := client.Post(endpoint, "application/json", bytes.NewBuffer(body))
@@ -69,14 +116,16 @@ func SendTelegramMessage(message string) error {
 	return nil
 }
 
-func renderCheckinTemplate(accountName string, success bool, result string) (string, error) {
-	tplStr := GetConfig("telegram.template")
-	if tplStr == "" {
-		tplStr = `<b>AnyRouter 签到系统</b>
+const defaultTelegramTemplate = `<b>AnyRouter 签到系统</b>
 用户名：<code>{{.Username}}</code>
 状态：{{if .Success}}<b>成功 ✅</b>{{else}}<b>失败 ❌</b>{{end}}
 结果：
 <pre>{{.Result}}</pre>`
+
+func renderCheckinTemplate(accountName string, success bool, result string) (string, error) {
+	tplStr := GetConfig("telegram.template")
+	if tplStr == "" {
+		tplStr = defaultTelegramTemplate
 	}
 
 	tpl, err := template.New("msg").Parse(tplStr)
@@ -96,12 +145,17 @@ func renderCheckinTemplate(accountName string, success bool, result string) (str
 	return buf.String(), nil
 }
 
-func SendCheckinNotification(accountName string, success bool, result string) {
+// SendCheckinNotification 推送签到结果，账号所有者设置了通知会话时发送到该会话。
+func SendCheckinNotification(ownerID uint, accountName string, success bool, result string) {
 	message, err := renderCheckinTemplate(accountName, success, result)
 	if err != nil {
 		return
 	}
-	SendTelegramMessage(message)
+	chatID := ""
+	if owner, err := repository.GetUserByID(ownerID); err == nil {
+		chatID = strings.TrimSpace(owner.TelegramChatID)
+	}
+	sendTelegramMessageTo(chatID, message)
 }
 
 func SendTestCheckinNotification() error {
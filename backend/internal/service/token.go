@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"anyrouter-checkin/internal/upstream"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	tokenKeyPrefix   = "sk-"
+	maxTokenNameLen  = 30
+	maxTokenPageSize = 100
+)
+
+var ErrInvalidToken = errors.New("令牌参数无效")
+
+// AccountToken 为上游 API 令牌，列表中的 Key 已脱敏。
+type AccountToken struct {
+	ID             int             `json:"id"`
+	Name           string          `json:"name"`
+	Key            string          `json:"key"`
+	Status         int             `json:"status"`
+	Group          string          `json:"group"`
+	CreatedTime    int64           `json:"created_time"`
+	AccessedTime   int64           `json:"accessed_time"`
+	ExpiredTime    int64           `json:"expired_time"`
+	UnlimitedQuota bool            `json:"unlimited_quota"`
+	RemainQuota    int64           `json:"remain_quota"`
+	UsedQuota      int64           `json:"used_quota"`
+	RemainBalance  decimal.Decimal `json:"remain_balance"`
+	UsedBalance    decimal.Decimal `json:"used_balance"`
+}
+
+type AccountTokenPage struct {
+	Items []AccountToken `json:"items"`
+	Total int64          `json:"total"`
+}
+
+// CreateTokenParams 中 QuotaLimit 以美元计，为 0 表示不限额；ExpiredTime 为 Unix 秒，为 0 表示永不过期。
+type CreateTokenParams struct {
+	Name        string
+	QuotaLimit  decimal.Decimal
+	ExpiredTime int64
+	Group       string
+}
+
+func ListAccountTokens(accountID uint, page, size int) (AccountTokenPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > maxTokenPageSize {
+		size = 20
+	}
+	_, cred, err := loadAccountCredential(accountID)
+	if err != nil {
+		return AccountTokenPage{}, err
+	}
+	result, err := upstreamAPI.Tokens(context.Background(), cred, page, size)
+	if err != nil {
+		return AccountTokenPage{}, err
+	}
+
+	items := make([]AccountToken, 0, len(result.Items))
+	for _, token := range result.Items {
+		item := toAccountToken(token)
+		item.Key = maskTokenKey(item.Key)
+		items = append(items, item)
+	}
+	return AccountTokenPage{Items: items, Total: result.Total}, nil
+}
+
+// CreateAccountToken 在上游创建令牌并返回包含完整 Key 的结果，完整 Key 仅在此处返回一次。
+func CreateAccountToken(accountID uint, params CreateTokenParams) (AccountToken, error) {
+	create, err := buildTokenCreate(params)
+	if err != nil {
+		return AccountToken{}, err
+	}
+	_, cred, err := loadAccountCredential(accountID)
+	if err != nil {
+		return AccountToken{}, err
+	}
+	ctx := context.Background()
+	if err := upstreamAPI.CreateToken(ctx, cred, create); err != nil {
+		return AccountToken{}, err
+	}
+
+	// 上游创建接口不返回令牌内容，按名称在第一页（ID 倒序）中取最新的一条。
+	result, err := upstreamAPI.Tokens(ctx, cred, 1, maxTokenPageSize)
+	if err != nil {
+		return AccountToken{}, fmt.Errorf("令牌已创建，但读取令牌失败: %w", err)
+	}
+	var created *upstream.Token
+	for i := range result.Items {
+		token := &result.Items[i]
+		if token.Name == create.Name && (created == nil || token.ID > created.ID) {
+			created = token
+		}
+	}
+	if created == nil {
+		return AccountToken{}, fmt.Errorf("令牌已创建，但未在列表中找到")
+	}
+	return toAccountToken(*created), nil
+}
+
+func DeleteAccountToken(accountID uint, tokenID int) error {
+	if tokenID <= 0 {
+		return fmt.Errorf("%w: 令牌ID无效", ErrInvalidToken)
+	}
+	_, cred, err := loadAccountCredential(accountID)
+	if err != nil {
+		return err
+	}
+	return upstreamAPI.DeleteToken(context.Background(), cred, tokenID)
+}
+
+func buildTokenCreate(params CreateTokenParams) (upstream.TokenCreate, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTokenNameLen {
+		return upstream.TokenCreate{}, fmt.Errorf("%w: 名称不能为空且不超过 %d 个字符", ErrInvalidToken, maxTokenNameLen)
+	}
+	if params.QuotaLimit.IsNegative() {
+		return upstream.TokenCreate{}, fmt.Errorf("%w: 额度不能为负数", ErrInvalidToken)
+	}
+	if params.ExpiredTime != 0 && params.ExpiredTime <= carbon.Now().Timestamp() {
+		return upstream.TokenCreate{}, fmt.Errorf("%w: 过期时间必须晚于当前时间", ErrInvalidToken)
+	}
+
+	create := upstream.TokenCreate{
+		Name:        name,
+		ExpiredTime: -1,
+		Group:       strings.TrimSpace(params.Group),
+	}
+	if params.ExpiredTime > 0 {
+		create.ExpiredTime = params.ExpiredTime
+	}
+	if params.QuotaLimit.IsZero() {
+		create.UnlimitedQuota = true
+	} else {
+		create.RemainQuota = params.QuotaLimit.Mul(upstream.QuotaPerUnit).IntPart()
+	}
+	return create, nil
+}
+
+func toAccountToken(token upstream.Token) AccountToken {
+	key := token.Key
+	if key != "" && !strings.HasPrefix(key, tokenKeyPrefix) {
+		key = tokenKeyPrefix + key
+	}
+	return AccountToken{
+		ID:             token.ID,
+		Name:           token.Name,
+		Key:            key,
+		Status:         token.Status,
+		Group:          token.Group,
+		CreatedTime:    token.CreatedTime,
+		AccessedTime:   token.AccessedTime,
+		ExpiredTime:    token.ExpiredTime,
+		UnlimitedQuota: token.UnlimitedQuota,
+		RemainQuota:    token.RemainQuota,
+		UsedQuota:      token.UsedQuota,
+		RemainBalance:  decimal.NewFromInt(token.RemainQuota).DivRound(upstream.QuotaPerUnit, 2),
+		UsedBalance:    decimal.NewFromInt(token.UsedQuota).DivRound(upstream.QuotaPerUnit, 2),
+	}
+}
+
+// maskTokenKey 仅保留前缀与首尾各 4 位。
+func maskTokenKey(key string) string {
+	body := strings.TrimPrefix(key, tokenKeyPrefix)
+	if len(body) <= 8 {
+		return tokenKeyPrefix + "****"
+	}
+	return tokenKeyPrefix + body[:4] + "****" + body[len(body)-4:]
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	maxRedeemCodes = 200
+	// BalanceSourceTopup 为兑换码充值引起的余额变化。
+	BalanceSourceTopup = "topup"
+)
+
+var ErrInvalidTopup = errors.New("充值参数无效")
+
+type TopupCodeResult struct {
+	AccountID  uint            `json:"account_id"`
+	Code       string          `json:"code"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	QuotaAdded int64           `json:"quota_added"`
+	Amount     decimal.Decimal `json:"amount"`
+}
+
+type TopupAccountResult struct {
+	AccountID     uint            `json:"account_id"`
+	Username      string          `json:"username"`
+	BalanceBefore decimal.Decimal `json:"balance_before"`
+	BalanceAfter  decimal.Decimal `json:"balance_after"`
+	Error         string          `json:"error,omitempty"`
+}
+
+type TopupResult struct {
+	Codes    []TopupCodeResult    `json:"codes"`
+	Accounts []TopupAccountResult `json:"accounts"`
+}
+
+// RedeemCodes 将兑换码按顺序轮流分配给目标账号并逐个提交，完成后刷新各账号余额并写入余额变化记录。
+// 单个兑换码失败不影响其余兑换码。
+func RedeemCodes(codes []string, accountIDs []uint) (TopupResult, error) {
+	codes = normalizeRedeemCodes(codes)
+	if len(codes) == 0 || len(codes) > maxRedeemCodes {
+		return TopupResult{}, fmt.Errorf("%w: 兑换码数量需为 1~%d 个", ErrInvalidTopup, maxRedeemCodes)
+	}
+	if len(accountIDs) == 0 {
+		return TopupResult{}, fmt.Errorf("%w: 未指定账号", ErrInvalidTopup)
+	}
+
+	accounts := make([]*model.Account, 0, len(accountIDs))
+	creds := make([]upstream.Credential, 0, len(accountIDs))
+	seen := make(map[uint]bool, len(accountIDs))
+	for _, id := range accountIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		account, cred, err := loadAccountCredential(id)
+		if err != nil {
+			return TopupResult{}, fmt.Errorf("账号 %d: %w", id, err)
+		}
+		accounts = append(accounts, account)
+		creds = append(creds, cred)
+	}
+
+	result := TopupResult{Codes: make([]TopupCodeResult, 0, len(codes))}
+	added := make([]int64, len(accounts))
+	for i, code := range codes {
+		idx := i % len(accounts)
+		item := redeemCode(accounts[idx].ID, creds[idx], code)
+		if item.Success {
+			added[idx] += item.QuotaAdded
+		}
+		result.Codes = append(result.Codes, item)
+	}
+
+	for i, account := range accounts {
+		result.Accounts = append(result.Accounts, settleTopupBalance(account, creds[i].UserID, added[i]))
+	}
+	return result, nil
+}
+
+func redeemCode(accountID uint, cred upstream.Credential, code string) TopupCodeResult {
+	item := TopupCodeResult{AccountID: accountID, Code: maskRedeemCode(code)}
+	quota, err := upstreamAPI.Redeem(context.Background(), cred, code)
+	if err != nil {
+		item.Message = err.Error()
+		zap.L().Warn("兑换码充值失败", zap.Uint("account_id", accountID), zap.String("code", item.Code), zap.Error(err))
+	} else {
+		item.Success = true
+		item.Message = "充值成功"
+		item.QuotaAdded = quota
+		item.Amount = decimal.NewFromInt(quota).DivRound(upstream.QuotaPerUnit, 2)
+	}
+
+	record := model.TopupRecord{
+		AccountID:  accountID,
+		Code:       item.Code,
+		Success:    item.Success,
+		Message:    truncateMessage(item.Message, 255),
+		QuotaAdded: item.QuotaAdded,
+		Amount:     item.Amount,
+	}
+	if err := repository.CreateTopupRecord(&record); err != nil {
+		zap.L().Error("保存充值记录失败", zap.Uint("account_id", accountID), zap.Error(err))
+	}
+	return item
+}
+
+// settleTopupBalance 刷新账号余额；刷新失败时按兑换结果估算余额变化，避免丢失记录。
+func settleTopupBalance(account *model.Account, userID int, quotaAdded int64) TopupAccountResult {
+	result := TopupAccountResult{
+		AccountID:     account.ID,
+		Username:      account.Username,
+		BalanceBefore: account.Balance,
+		BalanceAfter:  account.Balance,
+	}
+	addedAmount := decimal.NewFromInt(quotaAdded).DivRound(upstream.QuotaPerUnit, 2)
+
+	info, err := fetchAccountSelf(account, account.Session, userID)
+	if err != nil {
+		result.Error = "刷新余额失败: " + err.Error()
+		if quotaAdded == 0 {
+			return result
+		}
+		result.BalanceAfter = account.Balance.Add(addedAmount)
+	} else {
+		result.BalanceAfter = info.Balance
+	}
+	if quotaAdded == 0 && result.BalanceAfter.Equal(result.BalanceBefore) {
+		return result
+	}
+
+	account.Balance = result.BalanceAfter
+	if err := repository.SaveAccount(account); err != nil {
+		zap.L().Error("保存账号余额失败", zap.Uint("account_id", account.ID), zap.Error(err))
+	}
+	history := model.BalanceHistory{
+		AccountID: account.ID,
+		Source:    BalanceSourceTopup,
+		Change:    result.BalanceAfter.Sub(result.BalanceBefore),
+		Balance:   result.BalanceAfter,
+		Note:      fmt.Sprintf("兑换码充值 %s", addedAmount.StringFixed(2)),
+	}
+	if err := repository.CreateBalanceHistory(&history); err != nil {
+		zap.L().Error("保存余额变化失败", zap.Uint("account_id", account.ID), zap.Error(err))
+	}
+	return result
+}
+
+func ListTopupRecords(scope Scope, accountID uint, limit int) ([]model.TopupRecord, error) {
+	return repository.ListTopupRecords(scope, accountID, limit)
+}
+
+func ListBalanceHistory(accountID uint, limit int) ([]model.BalanceHistory, error) {
+	return repository.ListBalanceHistory(accountID, limit)
+}
+
+// normalizeRedeemCodes 去除空白与重复的兑换码，保持原有顺序。
+func normalizeRedeemCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	result := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		result = append(result, code)
+	}
+	return result
+}
+
+func maskRedeemCode(code string) string {
+	if len(code) <= 8 {
+		return "****"
+	}
+	return code[:4] + "****" + code[len(code)-4:]
+}
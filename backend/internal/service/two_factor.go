@@ -0,0 +1,299 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	loginChallengeTTLMinutes  = 5
+	loginChallengeMaxAttempts = 5
+	recoveryCodeCount         = 10
+)
+
+var (
+	ErrTwoFactorCode      = errors.New("验证码错误")
+	ErrTwoFactorChallenge = errors.New("登录验证已失效，请重新登录")
+	ErrTwoFactorEnabled   = errors.New("已开启两步验证")
+	ErrTwoFactorDisabled  = errors.New("未开启两步验证")
+)
+
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type loginChallenge struct {
+	userID    uint
+	ip        string
+	expiresAt *carbon.Carbon
+	attempts  int
+}
+
+var (
+	challengeMu     sync.Mutex
+	loginChallenges = make(map[string]*loginChallenge)
+)
+
+// newLoginChallenge 为已通过密码校验的用户创建一次性的两步验证挑战。
+func newLoginChallenge(userID uint, ip string) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	challengeMu.Lock()
+	defer challengeMu.Unlock()
+	now := carbon.Now()
+	for key, challenge := range loginChallenges {
+		if now.Gt(challenge.expiresAt) {
+			delete(loginChallenges, key)
+		}
+	}
+	loginChallenges[token] = &loginChallenge{userID: userID, ip: ip, expiresAt: now.AddMinutes(loginChallengeTTLMinutes)}
+	return token, nil
+}
+
+// CompleteTwoFactorLogin 校验挑战与动态码（或恢复码），成功后签发登录 Token。
+// 每个挑战最多尝试 5 次，超过后需重新输入密码；动态码错误同样计入登录失败次数。
+func CompleteTwoFactorLogin(challengeToken, code string, client LoginClient) (LoginResult, error) {
+	ip := client.IP
+	challengeMu.Lock()
+	challenge, ok := loginChallenges[challengeToken]
+	if ok && (carbon.Now().Gt(challenge.expiresAt) || challenge.attempts >= loginChallengeMaxAttempts) {
+		delete(loginChallenges, challengeToken)
+		ok = false
+	}
+	if ok {
+		challenge.attempts++
+	}
+	challengeMu.Unlock()
+	if !ok {
+		return LoginResult{}, ErrTwoFactorChallenge
+	}
+
+	user, err := repository.GetUserByID(challenge.userID)
+	if err != nil || user.Status != 1 || !user.TOTPEnabled || challenge.ip != ip {
+		return LoginResult{}, ErrTwoFactorChallenge
+	}
+	if err := checkLoginLocked(user.Username, ip); err != nil {
+		return LoginResult{}, err
+	}
+	if err := verifySecondFactor(user, code); err != nil {
+		if errors.Is(err, ErrTwoFactorCode) {
+			if lockErr := failLogin(user.Username, ip, LoginFailureWrongCode); !errors.Is(lockErr, ErrInvalidCredentials) {
+				return LoginResult{}, lockErr
+			}
+		}
+		return LoginResult{}, err
+	}
+
+	challengeMu.Lock()
+	delete(loginChallenges, challengeToken)
+	challengeMu.Unlock()
+	resetLoginFailures(user.Username)
+	return startLoginSession(user, client)
+}
+
+// SetupTwoFactor 生成新的 TOTP 密钥，需调用 EnableTwoFactor 验证首个动态码后才会生效。
+func SetupTwoFactor(userID uint) (TwoFactorSetup, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	if user.TOTPEnabled {
+		return TwoFactorSetup{}, ErrTwoFactorEnabled
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	encrypted, err := encryptSecret(secret)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	user.TOTPSecret = encrypted
+	user.TOTPLastStep = 0
+	if err := repository.SaveUser(user); err != nil {
+		return TwoFactorSetup{}, err
+	}
+	return TwoFactorSetup{Secret: secret, URI: totpURI(secret, user.Username)}, nil
+}
+
+// EnableTwoFactor 验证首个动态码并开启两步验证，返回仅显示一次的恢复码。
+func EnableTwoFactor(userID uint, code string) ([]string, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorDisabled
+	}
+	secret, err := decryptSecret(user.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := verifyTOTP(secret, code, carbon.Now(), 0)
+	if !ok {
+		return nil, ErrTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	user.RecoveryCodes = hashes
+	if err := repository.SaveUser(user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor 校验密码与动态码（或恢复码）后关闭两步验证。
+func DisableTwoFactor(userID uint, password, code string) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorDisabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.New("密码错误")
+	}
+	if err := verifySecondFactor(user, code); err != nil {
+		return err
+	}
+	return ResetTwoFactor(userID)
+}
+
+// RegenerateRecoveryCodes 校验动态码后重新生成恢复码，旧恢复码全部失效。
+func RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorDisabled
+	}
+	if err := verifySecondFactor(user, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	user.RecoveryCodes = hashes
+	if err := repository.SaveUser(user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetTwoFactor 清除用户的两步验证设置，供用户关闭或管理员在用户丢失设备时使用。
+func ResetTwoFactor(userID uint) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = ""
+	return repository.SaveUser(user)
+}
+
+// verifySecondFactor 校验 6 位动态码或一次性恢复码，成功后记录已使用的时间步或作废该恢复码。
+// 记录与作废均为条件更新，并发提交同一动态码或恢复码时只有一个请求通过，其余视为重放。
+func verifySecondFactor(user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		secret, err := decryptSecret(user.TOTPSecret)
+		if err != nil {
+			return err
+		}
+		step, ok := verifyTOTP(secret, code, carbon.Now(), user.TOTPLastStep)
+		if !ok {
+			return ErrTwoFactorCode
+		}
+		updated, err := repository.UpdateTOTPLastStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrTwoFactorCode
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	var hashes []string
+	if user.RecoveryCodes != "" {
+		if err := json.Unmarshal([]byte(user.RecoveryCodes), &hashes); err != nil {
+			return err
+		}
+	}
+	idx := slices.Index(hashes, hashRecoveryCode(code))
+	if idx < 0 {
+		return ErrTwoFactorCode
+	}
+	hashes = slices.Delete(hashes, idx, idx+1)
+	payload, err := json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+	updated, err := repository.UpdateRecoveryCodes(user.ID, user.RecoveryCodes, string(payload))
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrTwoFactorCode
+	}
+	user.RecoveryCodes = string(payload)
+	return nil
+}
+
+// generateRecoveryCodes 返回明文恢复码及其哈希的 JSON，数据库中只保存哈希。
+func generateRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, "", err
+		}
+		raw := hex.EncodeToString(buf)
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	payload, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(payload), nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
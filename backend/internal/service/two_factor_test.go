@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// setupTwoFactorUser 为默认管理员开启两步验证，返回 TOTP 密钥、当前时间步与恢复码。
+func setupTwoFactorUser(t *testing.T) (*model.User, string, int64, []string) {
+	t.Helper()
+	user := setupTestAdmin(t)
+	now := carbon.Parse("2026-03-01 08:00:00")
+	carbon.SetTestNow(now)
+	setup, err := SetupTwoFactor(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := now.Timestamp() / totpPeriod
+	code, err := totpCode(setup.Secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes, err := EnableTwoFactor(user.ID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, setup.Secret, step, recoveryCodes
+}
+
+// 两个请求在对方记录之前都读到了同一份用户数据，模拟并发提交同一验证码：只有先记录的一个通过。
+func TestVerifySecondFactorReplay(t *testing.T) {
+	tests := []struct {
+		name string
+		code func(t *testing.T, secret string, step int64, recoveryCodes []string) string
+	}{
+		{"动态码", func(t *testing.T, secret string, step int64, _ []string) string {
+			// 启用时已使用当前时间步，下一个时间步仍在允许的偏差内。
+			code, err := totpCode(secret, step+1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return code
+		}},
+		{"恢复码", func(_ *testing.T, _ string, _ int64, recoveryCodes []string) string {
+			return recoveryCodes[0]
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, secret, step, recoveryCodes := setupTwoFactorUser(t)
+			code := tt.code(t, secret, step, recoveryCodes)
+
+			first, err := repository.GetUserByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := repository.GetUserByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := verifySecondFactor(first, code); err != nil {
+				t.Fatalf("首次提交应通过: %v", err)
+			}
+			if err := verifySecondFactor(second, code); !errors.Is(err, ErrTwoFactorCode) {
+				t.Fatalf("并发重放应被拒绝: %v", err)
+			}
+			latest, err := repository.GetUserByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := verifySecondFactor(latest, code); !errors.Is(err, ErrTwoFactorCode) {
+				t.Fatalf("再次提交应被拒绝: %v", err)
+			}
+		})
+	}
+}
+
+func TestCompleteTwoFactorLoginChallengeExpiry(t *testing.T) {
+	user, secret, step, _ := setupTwoFactorUser(t)
+	client := LoginClient{IP: "192.0.2.1"}
+	login := func() string {
+		t.Helper()
+		result, err := Login(user.Username, config.Get().Admin.Password, client)
+		if err != nil || !result.TwoFactorRequired {
+			t.Fatalf("开启两步验证后应返回挑战: %+v, %v", result, err)
+		}
+		return result.ChallengeToken
+	}
+
+	code, err := totpCode(secret, step+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := CompleteTwoFactorLogin(login(), code, client); err != nil || result.Token == "" {
+		t.Fatalf("有效期内应完成登录: %+v, %v", result, err)
+	}
+
+	challenge := login()
+	carbon.SetTestNow(carbon.Now().AddMinutes(loginChallengeTTLMinutes).AddSecond())
+	if code, err = totpCode(secret, carbon.Now().Timestamp()/totpPeriod); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CompleteTwoFactorLogin(challenge, code, client); !errors.Is(err, ErrTwoFactorChallenge) {
+		t.Fatalf("过期的挑战应被拒绝: %v", err)
+	}
+}
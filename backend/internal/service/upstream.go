@@ -0,0 +1,43 @@
+package service
+
+import (
+	"strings"
+
+	"anyrouter-checkin/internal/config"
+	"anyrouter-checkin/internal/upstream"
+
+	"go.uber.org/zap"
+)
+
+// ChallengeError 为上游 WAF 挑战无法处理时返回的错误类型。
+type ChallengeError = upstream.ChallengeError
+
+// UpstreamAPIError 为上游返回 success=false 或异常状态码时的错误类型。
+type UpstreamAPIError = upstream.APIError
+
+var upstreamAPI upstream.Client = upstream.NewClient(upstream.DefaultBaseURL)
+
+func init() {
+	upstream.Settings = GetConfig
+	config.Subscribe(func(old, new *config.Config) {
+		if old.Upstream == new.Upstream && old.Proxy == new.Proxy {
+			return
+		}
+		upstream.ResetTransports()
+		InitUpstream(new.Upstream.BaseURL)
+		zap.L().Info("上游配置已更新", zap.String("base_url", new.Upstream.BaseURL))
+	})
+}
+
+// SetUpstreamClient 替换访问上游使用的 Client，便于接入模拟实现。
+func SetUpstreamClient(client upstream.Client) {
+	upstreamAPI = client
+}
+
+// InitUpstream 按配置的站点地址初始化 Client，为空时使用 AnyRouter 官方地址。
+func InitUpstream(baseURL string) {
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = upstream.DefaultBaseURL
+	}
+	SetUpstreamClient(upstream.NewClient(baseURL))
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+	"anyrouter-checkin/internal/upstream"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	// usageLogType 为上游日志类型中的消费日志。
+	usageLogType      = 2
+	usageSyncPageSize = 100
+	// usageSyncMaxPages 限制单次同步的翻页数，超出部分留待下次同步。
+	usageSyncMaxPages = 50
+	// usageInitialDays 为首次同步时回溯的天数。
+	usageInitialDays = 30
+	maxUsagePageSize = 200
+)
+
+var ErrInvalidUsageQuery = errors.New("查询参数无效")
+
+type UsageFilter = repository.UsageFilter
+
+type UsageSyncResult struct {
+	AccountID uint  `json:"account_id"`
+	Fetched   int   `json:"fetched"`
+	Inserted  int64 `json:"inserted"`
+	Truncated bool  `json:"truncated"`
+}
+
+type UsageRecordPage struct {
+	Items []model.UsageRecord `json:"items"`
+	Total int64               `json:"total"`
+}
+
+type UsageStatItem struct {
+	repository.UsageStat
+	Username string          `json:"username,omitempty"`
+	Cost     decimal.Decimal `json:"cost"`
+}
+
+// SyncAccountUsage 从上游拉取游标之后的消费日志。上游按 ID 倒序返回，遇到已同步的日志即停止翻页。
+func SyncAccountUsage(accountID uint) (UsageSyncResult, error) {
+	result := UsageSyncResult{AccountID: accountID}
+	cursor, err := repository.GetUsageCursor(accountID)
+	if err != nil {
+		return result, err
+	}
+
+	records, truncated, err := fetchUsageSince(accountID, cursor)
+	now := carbon.DateTime{Carbon: carbon.Now()}
+	cursor.LastSyncAt = &now
+	if err != nil {
+		cursor.LastError = truncateMessage(err.Error(), 255)
+		if saveErr := repository.SaveUsageCursor(cursor); saveErr != nil {
+			zap.L().Warn("保存同步游标失败", zap.Uint("account_id", accountID), zap.Error(saveErr))
+		}
+		return result, err
+	}
+
+	inserted, err := repository.CreateUsageRecords(records)
+	if err != nil {
+		return result, err
+	}
+	for _, record := range records {
+		if record.UpstreamID > cursor.LastLogID {
+			cursor.LastLogID = record.UpstreamID
+		}
+		if ts := record.LoggedAt.Timestamp(); ts > cursor.LastTimestamp {
+			cursor.LastTimestamp = ts
+		}
+	}
+	cursor.LastError = ""
+	if err := repository.SaveUsageCursor(cursor); err != nil {
+		return result, err
+	}
+
+	result.Fetched = len(records)
+	result.Inserted = inserted
+	result.Truncated = truncated
+	if truncated {
+		zap.L().Warn("消费日志超过单次同步上限", zap.Uint("account_id", accountID), zap.Int("fetched", len(records)))
+	}
+	return result, nil
+}
+
+func fetchUsageSince(accountID uint, cursor *model.UsageCursor) ([]model.UsageRecord, bool, error) {
+	_, cred, err := loadAccountCredential(accountID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	start := cursor.LastTimestamp
+	if start == 0 {
+		start = carbon.Now().SubDays(usageInitialDays).Timestamp()
+	}
+
+	var records []model.UsageRecord
+	for page := 1; page <= usageSyncMaxPages; page++ {
+		logs, err := upstreamAPI.Logs(context.Background(), cred, upstream.LogQuery{
+			Type:           usageLogType,
+			Page:           page,
+			PageSize:       usageSyncPageSize,
+			StartTimestamp: start,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+
+		reachedSynced := false
+		for _, entry := range logs.Items {
+			if entry.ID <= cursor.LastLogID {
+				reachedSynced = true
+				continue
+			}
+			records = append(records, toUsageRecord(accountID, entry))
+		}
+		if reachedSynced || len(logs.Items) < usageSyncPageSize {
+			return records, false, nil
+		}
+	}
+	return records, true, nil
+}
+
+func toUsageRecord(accountID uint, entry upstream.LogEntry) model.UsageRecord {
+	loggedAt := carbon.CreateFromTimestamp(entry.CreatedAt)
+	return model.UsageRecord{
+		AccountID:        accountID,
+		UpstreamID:       entry.ID,
+		ModelName:        entry.ModelName,
+		TokenName:        entry.TokenName,
+		Group:            entry.Group,
+		PromptTokens:     entry.PromptTokens,
+		CompletionTokens: entry.CompletionTokens,
+		Quota:            entry.Quota,
+		Cost:             decimal.NewFromInt(entry.Quota).DivRound(upstream.QuotaPerUnit, 6),
+		UseTime:          entry.UseTime,
+		IsStream:         entry.IsStream,
+		LogDate:          loggedAt.ToDateString(),
+		LoggedAt:         carbon.DateTime{Carbon: loggedAt},
+	}
+}
+
+// syncUsageForAccounts 供定时任务调用，逐个同步启用中的账号。
+func syncUsageForAccounts(accountIDs []uint) {
+	for _, accID := range accountIDs {
+		result, err := SyncAccountUsage(accID)
+		if err != nil {
+			zap.L().Warn("同步消费日志失败", zap.Uint("account_id", accID), zap.Error(err))
+			continue
+		}
+		zap.L().Info("同步消费日志完成", zap.Uint("account_id", accID), zap.Int("fetched", result.Fetched), zap.Int64("inserted", result.Inserted))
+	}
+}
+
+func ListUsageCursors(scope Scope) ([]model.UsageCursor, error) {
+	return repository.ListUsageCursors(scope)
+}
+
+func ListUsageRecords(filter UsageFilter, page, size int) (UsageRecordPage, error) {
+	if err := validateUsageFilter(filter); err != nil {
+		return UsageRecordPage{}, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > maxUsagePageSize {
+		size = 50
+	}
+	records, total, err := repository.ListUsageRecords(filter, page, size)
+	if err != nil {
+		return UsageRecordPage{}, err
+	}
+	return UsageRecordPage{Items: records, Total: total}, nil
+}
+
+// UsageStats 返回按日期与 groupBy（account / model / token）聚合的消费统计，未指定日期时默认最近 30 天。
+func UsageStats(filter UsageFilter, groupBy string) ([]UsageStatItem, error) {
+	switch groupBy {
+	case "":
+		groupBy = "account"
+	case "account", "model", "token":
+	default:
+		return nil, fmt.Errorf("%w: group_by 仅支持 account、model、token", ErrInvalidUsageQuery)
+	}
+	if filter.StartDate == "" && filter.EndDate == "" {
+		filter.StartDate = carbon.Now().SubDays(usageInitialDays).ToDateString()
+	}
+	if err := validateUsageFilter(filter); err != nil {
+		return nil, err
+	}
+
+	stats, err := repository.SumUsage(filter, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[uint]string)
+	if groupBy == "account" {
+		accounts, err := repository.ListAccounts()
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range accounts {
+			usernames[account.ID] = account.Username
+		}
+	}
+
+	items := make([]UsageStatItem, 0, len(stats))
+	for _, stat := range stats {
+		items = append(items, UsageStatItem{
+			UsageStat: stat,
+			Username:  usernames[stat.AccountID],
+			Cost:      decimal.NewFromInt(stat.Quota).DivRound(upstream.QuotaPerUnit, 6),
+		})
+	}
+	return items, nil
+}
+
+func validateUsageFilter(filter UsageFilter) error {
+	for _, date := range []string{filter.StartDate, filter.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("%w: 日期格式应为 YYYY-MM-DD", ErrInvalidUsageQuery)
+		}
+	}
+	if filter.StartDate != "" && filter.EndDate != "" && filter.StartDate > filter.EndDate {
+		return fmt.Errorf("%w: 开始日期不能晚于结束日期", ErrInvalidUsageQuery)
+	}
+	return nil
+}
+
+func truncateMessage(message string, limit int) string {
+	runes := []rune(message)
+	if len(runes) <= limit {
+		return message
+	}
+	return string(runes[:limit])
+}
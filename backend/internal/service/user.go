@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"anyrouter-checkin/internal/middleware"
+	"anyrouter-checkin/internal/model"
+	"anyrouter-checkin/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const minPasswordLength = 6
+
+var (
+	ErrInvalidUser  = errors.New("用户参数无效")
+	ErrUserDisabled = errors.New("用户已禁用")
+	ErrUserExists   = errors.New("用户名已存在")
+	ErrLastAdmin    = errors.New("至少需要保留一个启用的管理员")
+	ErrUserNotFound = errors.New("用户不存在")
+)
+
+func ListUsers() ([]model.User, error) {
+	return repository.ListUsers()
+}
+
+func GetUser(id uint) (model.User, error) {
+	user, err := repository.GetUserByID(id)
+	if err != nil {
+		return model.User{}, err
+	}
+	return *user, nil
+}
+
+func CreateUser(username, password, role string) (model.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || utf8.RuneCountInString(username) > 50 {
+		return model.User{}, fmt.Errorf("%w: 用户名不能为空且不超过 50 个字符", ErrInvalidUser)
+	}
+	if !middleware.ValidRole(role) {
+		return model.User{}, fmt.Errorf("%w: 角色无效", ErrInvalidUser)
+	}
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return model.User{}, err
+	}
+	if _, err := repository.GetUserByUsername(username); err == nil {
+		return model.User{}, ErrUserExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.User{}, err
+	}
+
+	user := model.User{
+		Username: username,
+		Password: hashed,
+		Role:     role,
+		Status:   1,
+	}
+	if err := repository.CreateUser(&user); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// UpdateUser 修改用户角色、状态与所属团队，不允许操作者禁用或降级自己，也不允许移除最后一个启用的管理员。
+func UpdateUser(operatorID, id uint, role string, status int, teamID *uint) (model.User, error) {
+	if !middleware.ValidRole(role) {
+		return model.User{}, fmt.Errorf("%w: 角色无效", ErrInvalidUser)
+	}
+	if status != 0 && status != 1 {
+		return model.User{}, fmt.Errorf("%w: 状态无效", ErrInvalidUser)
+	}
+	if teamID != nil {
+		if _, err := repository.GetTeamByID(*teamID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return model.User{}, fmt.Errorf("%w: 团队不存在", ErrInvalidUser)
+			}
+			return model.User{}, err
+		}
+	}
+	user, err := repository.GetUserByID(id)
+	if err != nil {
+		return model.User{}, err
+	}
+	demoted := user.Role == model.RoleAdmin && user.Status == 1 && (role != model.RoleAdmin || status != 1)
+	if demoted {
+		if operatorID == id {
+			return model.User{}, fmt.Errorf("%w: 不能禁用或降级自己", ErrInvalidUser)
+		}
+		count, err := repository.CountActiveAdmins(id)
+		if err != nil {
+			return model.User{}, err
+		}
+		if count == 0 {
+			return model.User{}, ErrLastAdmin
+		}
+	}
+
+	user.Role = role
+	user.Status = status
+	user.TeamID = teamID
+	if err := repository.SaveUser(user); err != nil {
+		return model.User{}, err
+	}
+	return *user, nil
+}
+
+// ResetUserPassword 由管理员为用户设置新密码，无需原密码，并吊销其全部会话。
+func ResetUserPassword(id uint, password string) error {
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if _, err := repository.GetUserByID(id); err != nil {
+		return err
+	}
+	return repository.UpdateUserPassword(id, hashed)
+}
+
+// SetNotificationChat 设置用户自己的 Telegram 通知会话，为空时使用系统默认会话。
+func SetNotificationChat(userID uint, chatID string) (model.User, error) {
+	chatID = strings.TrimSpace(chatID)
+	if utf8.RuneCountInString(chatID) > 100 {
+		return model.User{}, fmt.Errorf("%w: 会话 ID 过长", ErrInvalidUser)
+	}
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	user.TelegramChatID = chatID
+	if err := repository.SaveUser(user); err != nil {
+		return model.User{}, err
+	}
+	return *user, nil
+}
+
+func hashPassword(password string) (string, error) {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return "", fmt.Errorf("%w: 密码至少 %d 位", ErrInvalidUser, minPasswordLength)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.New("密码加密失败")
+	}
+	return string(hashed), nil
+}
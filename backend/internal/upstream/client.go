@@ -0,0 +1,296 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type apiClient struct {
+	baseURL string
+}
+
+// NewClient 返回访问指定站点的 Client，所有请求经由 Transport 共享连接池。
+func NewClient(baseURL string) Client {
+	return &apiClient{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+type envelope struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type pagedItems[T any] struct {
+	Items []T   `json:"items"`
+	Total int64 `json:"total"`
+}
+
+func (c *apiClient) BaseURL() string {
+	return c.baseURL
+}
+
+func (c *apiClient) WafCookie(ctx context.Context, identity Identity) (string, error) {
+	return getWafCookie(ctx, c.baseURL, identity)
+}
+
+func (c *apiClient) InvalidateWafCookie(identity Identity) {
+	invalidateWafCookie(c.baseURL, identity)
+}
+
+func (c *apiClient) SignIn(ctx context.Context, cred Credential) (*SignInResult, error) {
+	resp, body, err := c.send(ctx, cred, "POST", "/api/user/sign_in", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrUnauthorized
+	}
+
+	result := &SignInResult{Raw: string(body)}
+	var payload envelope
+	if err := json.Unmarshal(body, &payload); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &APIError{Endpoint: "sign_in", StatusCode: resp.StatusCode}
+		}
+		return result, nil
+	}
+	if !payload.Success && isUnauthorizedMessage(payload.Message) {
+		return nil, ErrUnauthorized
+	}
+	result.Success = payload.Success
+	result.Message = payload.Message
+	result.AlreadySigned = strings.Contains(payload.Message, "已签到") || strings.Contains(strings.ToLower(payload.Message), "already")
+	return result, nil
+}
+
+func (c *apiClient) Self(ctx context.Context, cred Credential) (*UserSelf, error) {
+	data, err := c.call(ctx, cred, "GET", "/api/user/self", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var self struct {
+		ID       int    `json:"id"`
+		Username string `json:"username"`
+		Role     int    `json:"role"`
+		Status   int    `json:"status"`
+		Quota    int64  `json:"quota"`
+	}
+	if err := json.Unmarshal(data, &self); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+	return &UserSelf{
+		ID:       self.ID,
+		Username: self.Username,
+		Role:     self.Role,
+		Status:   self.Status,
+		Quota:    self.Quota,
+	}, nil
+}
+
+func (c *apiClient) Tokens(ctx context.Context, cred Credential, page, size int) (*TokenPage, error) {
+	query := url.Values{}
+	query.Set("p", strconv.Itoa(page))
+	query.Set("page_size", strconv.Itoa(size))
+	query.Set("size", strconv.Itoa(size))
+	data, err := c.call(ctx, cred, "GET", "/api/token/", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	items, total, err := decodePaged[Token](data)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPage{Items: items, Total: total}, nil
+}
+
+func (c *apiClient) CreateToken(ctx context.Context, cred Credential, token TokenCreate) error {
+	_, err := c.call(ctx, cred, "POST", "/api/token/", nil, token)
+	return err
+}
+
+func (c *apiClient) DeleteToken(ctx context.Context, cred Credential, id int) error {
+	_, err := c.call(ctx, cred, "DELETE", "/api/token/"+strconv.Itoa(id), nil, nil)
+	return err
+}
+
+func (c *apiClient) Logs(ctx context.Context, cred Credential, q LogQuery) (*LogPage, error) {
+	query := url.Values{}
+	query.Set("p", strconv.Itoa(q.Page))
+	query.Set("page_size", strconv.Itoa(q.PageSize))
+	query.Set("type", strconv.Itoa(q.Type))
+	if q.StartTimestamp > 0 {
+		query.Set("start_timestamp", strconv.FormatInt(q.StartTimestamp, 10))
+	}
+	if q.EndTimestamp > 0 {
+		query.Set("end_timestamp", strconv.FormatInt(q.EndTimestamp, 10))
+	}
+	data, err := c.call(ctx, cred, "GET", "/api/log/self", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	items, total, err := decodePaged[LogEntry](data)
+	if err != nil {
+		return nil, err
+	}
+	return &LogPage{Items: items, Total: total}, nil
+}
+
+func (c *apiClient) Redeem(ctx context.Context, cred Credential, code string) (int64, error) {
+	data, err := c.call(ctx, cred, "POST", "/api/user/topup", nil, map[string]string{"key": code})
+	if err != nil {
+		return 0, err
+	}
+	var quota int64
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		if err := json.Unmarshal(trimmed, &quota); err != nil {
+			return 0, fmt.Errorf("解析响应失败: %v", err)
+		}
+	}
+	return quota, nil
+}
+
+// decodePaged 兼容 New-API 新旧两种分页结构：直接数组，或 {items, total}。
+func decodePaged[T any](data json.RawMessage) ([]T, int64, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return []T{}, 0, nil
+	}
+	if trimmed[0] == '[' {
+		var items []T
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, 0, fmt.Errorf("解析响应失败: %v", err)
+		}
+		return items, int64(len(items)), nil
+	}
+	var paged pagedItems[T]
+	if err := json.Unmarshal(trimmed, &paged); err != nil {
+		return nil, 0, fmt.Errorf("解析响应失败: %v", err)
+	}
+	if paged.Items == nil {
+		paged.Items = []T{}
+	}
+	return paged.Items, paged.Total, nil
+}
+
+// call 发送请求并校验 New-API 的 {success, message, data} 响应结构，返回 data 字段。
+func (c *apiClient) call(ctx context.Context, cred Credential, method, path string, query url.Values, payload interface{}) (json.RawMessage, error) {
+	resp, body, err := c.send(ctx, cred, method, path, query, payload)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrUnauthorized
+	}
+
+	var result envelope
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &APIError{Endpoint: path, StatusCode: resp.StatusCode}
+		}
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+	if !result.Success {
+		if isUnauthorizedMessage(result.Message) {
+			return nil, ErrUnauthorized
+		}
+		msg := result.Message
+		if msg == "" {
+			msg = "请求失败"
+		}
+		return nil, &APIError{Endpoint: path, StatusCode: resp.StatusCode, Message: msg}
+	}
+	return result.Data, nil
+}
+
+// send 携带缓存的 WAF Cookie 发送请求；若响应仍是挑战页，则刷新 Cookie 后重试一次。
+func (c *apiClient) send(ctx context.Context, cred Credential, method, path string, query url.Values, payload interface{}) (*http.Response, []byte, error) {
+	if cred.Session == "" {
+		return nil, nil, fmt.Errorf("%w: session 为空", ErrUnauthorized)
+	}
+	client, err := httpClient(cred.Identity.ProxyURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var payloadBytes []byte
+	if payload != nil {
+		if payloadBytes, err = json.Marshal(payload); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		wafCookie, err := c.WafCookie(ctx, cred.Identity)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var reqBody io.Reader
+		if payloadBytes != nil {
+			reqBody = bytes.NewReader(payloadBytes)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建请求失败: %v", err)
+		}
+		req.Header.Set("accept", "application/json, text/plain, */*")
+		req.Header.Set("cache-control", "no-store")
+		req.Header.Set("pragma", "no-cache")
+		req.Header.Set("origin", c.baseURL)
+		req.Header.Set("referer", c.baseURL+"/console/personal")
+		if payloadBytes != nil {
+			req.Header.Set("content-type", "application/json")
+		}
+		cred.Apply(req.Header, wafCookie)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("请求失败: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取响应失败: %v", err)
+		}
+		if !IsWafChallenge(body) {
+			notifySessionRotation(resp, cred)
+			return resp, body, nil
+		}
+		zap.L().Info("命中 WAF 挑战页，刷新 WAF Cookie", zap.String("url", target))
+		c.InvalidateWafCookie(cred.Identity)
+	}
+	return nil, nil, &ChallengeError{Err: fmt.Errorf("刷新 Cookie 后仍返回挑战页")}
+}
+
+func notifySessionRotation(resp *http.Response, cred Credential) {
+	if cred.OnSessionRotated == nil {
+		return
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "session" && cookie.Value != "" && cookie.Value != cred.Session && cookie.MaxAge >= 0 {
+			cred.OnSessionRotated(cookie.Value)
+			return
+		}
+	}
+}
+
+func isUnauthorizedMessage(message string) bool {
+	if message == "" {
+		return false
+	}
+	lower := strings.ToLower(message)
+	return strings.Contains(message, "未授权") || strings.Contains(lower, "unauthorized")
+}
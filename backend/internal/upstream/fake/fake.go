@@ -0,0 +1,623 @@
+// Package fake 提供模拟 AnyRouter（New-API）站点的 HTTP 服务，用于本地联调与集成测试。
+//
+// 支持 acw_sc__v2 WAF 挑战页、/api/user/sign_in、/api/user/self、/api/token/、/api/log/self、/api/user/topup、/v1/chat/completions 以及 session 轮换，
+// 下发的 session 与真实站点格式一致，可被 service.ParseSession 解析。
+package fake
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"anyrouter-checkin/internal/upstream"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// DefaultSignInQuota 为每次签到成功增加的 quota，对应 0.5 美元。
+const DefaultSignInQuota int64 = 250000
+
+type User struct {
+	ID       int
+	Username string
+	Role     int
+	Status   int
+	Group    string
+	Quota    int64
+
+	lastSignIn string
+}
+
+type Token struct {
+	ID             int    `json:"id"`
+	UserID         int    `json:"-"`
+	Name           string `json:"name"`
+	Key            string `json:"key"`
+	Status         int    `json:"status"`
+	CreatedTime    int64  `json:"created_time"`
+	AccessedTime   int64  `json:"accessed_time"`
+	ExpiredTime    int64  `json:"expired_time"`
+	RemainQuota    int64  `json:"remain_quota"`
+	UsedQuota      int64  `json:"used_quota"`
+	UnlimitedQuota bool   `json:"unlimited_quota"`
+	Group          string `json:"group"`
+}
+
+type Log struct {
+	ID               int64  `json:"id"`
+	UserID           int    `json:"-"`
+	CreatedAt        int64  `json:"created_at"`
+	Type             int    `json:"type"`
+	Content          string `json:"content"`
+	TokenName        string `json:"token_name"`
+	ModelName        string `json:"model_name"`
+	Quota            int64  `json:"quota"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	UseTime          int    `json:"use_time"`
+	IsStream         bool   `json:"is_stream"`
+	Group            string `json:"group"`
+}
+
+// Server 为模拟上游，零值不可用，请使用 New 创建。
+type Server struct {
+	mu          sync.Mutex
+	secret      []byte
+	nextID      int
+	nextTokenID int
+	users       map[int]*User
+	tokens      []*Token
+	logs        []Log
+	redeemCodes map[string]int64
+	sessions    map[string]int
+	wafAnswers  map[string]bool
+	challenge   bool
+	rotate      bool
+	signInQuota int64
+}
+
+func New() *Server {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return &Server{
+		secret:      secret,
+		nextID:      1,
+		nextTokenID: 1,
+		users:       make(map[int]*User),
+		sessions:    make(map[string]int),
+		wafAnswers:  make(map[string]bool),
+		redeemCodes: make(map[string]int64),
+		signInQuota: DefaultSignInQuota,
+	}
+}
+
+// Start 启动 httptest 服务，调用方负责 Close。
+func (s *Server) Start() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+// SetChallenge 开启后，未携带有效 acw_sc__v2 Cookie 的请求返回 WAF 挑战页。
+func (s *Server) SetChallenge(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.challenge = enabled
+}
+
+// RotateChallenge 使已下发的全部 acw_sc__v2 答案失效，模拟站点轮换挑战，之后的请求需重新求解。
+func (s *Server) RotateChallenge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wafAnswers = make(map[string]bool)
+}
+
+// SetSessionRotation 开启后，已认证请求的响应会下发新的 session Cookie，旧 session 仍然有效。
+func (s *Server) SetSessionRotation(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotate = enabled
+}
+
+func (s *Server) SetSignInQuota(quota int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signInQuota = quota
+}
+
+// AddUser 创建用户并返回其 ID 与一个可用的 session。
+func (s *Server) AddUser(username string, quota int64) (int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := &User{
+		ID:       s.nextID,
+		Username: username,
+		Role:     1,
+		Status:   1,
+		Group:    "default",
+		Quota:    quota,
+	}
+	s.nextID++
+	s.users[user.ID] = user
+	return user.ID, s.issueSessionLocked(user)
+}
+
+// User 返回用户的快照，不存在时返回 false。
+func (s *Server) User(id int) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return User{}, false
+	}
+	return *user, true
+}
+
+// IssueSession 为已有用户签发新的 session。
+func (s *Server) IssueSession(id int) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return "", false
+	}
+	return s.issueSessionLocked(user), true
+}
+
+// RevokeSession 使 session 失效，后续请求返回 401。
+func (s *Server) RevokeSession(session string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, session)
+}
+
+func (s *Server) SetUserStatus(id, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, ok := s.users[id]; ok {
+		user.Status = status
+	}
+}
+
+// ResetSignIn 清除用户当天的签到记录，便于重复演练签到流程。
+func (s *Server) ResetSignIn(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, ok := s.users[id]; ok {
+		user.lastSignIn = ""
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.passChallenge(w, r) {
+		return
+	}
+	switch {
+	case r.URL.Path == "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<!doctype html><html><body>AnyRouter</body></html>"))
+	case r.URL.Path == "/api/user/sign_in" && r.Method == http.MethodPost:
+		s.handleSignIn(w, r)
+	case r.URL.Path == "/api/user/self" && r.Method == http.MethodGet:
+		s.handleSelf(w, r)
+	case r.URL.Path == "/api/token/" && r.Method == http.MethodGet:
+		s.handleListTokens(w, r)
+	case r.URL.Path == "/api/token/" && r.Method == http.MethodPost:
+		s.handleCreateToken(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/token/") && r.Method == http.MethodDelete:
+		s.handleDeleteToken(w, r)
+	case r.URL.Path == "/api/log/self" && r.Method == http.MethodGet:
+		s.handleListLogs(w, r)
+	case r.URL.Path == "/api/user/topup" && r.Method == http.MethodPost:
+		s.handleTopup(w, r)
+	case r.URL.Path == "/v1/chat/completions" && r.Method == http.MethodPost:
+		s.handleChatCompletions(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, false, "not found", nil)
+	}
+}
+
+// passChallenge 在开启挑战时校验 acw_sc__v2 Cookie，未通过则写入挑战页。
+func (s *Server) passChallenge(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	enabled := s.challenge
+	s.mu.Unlock()
+	if !enabled {
+		return true
+	}
+	if cookie, err := r.Cookie("acw_sc__v2"); err == nil {
+		s.mu.Lock()
+		ok := s.wafAnswers[cookie.Value]
+		s.mu.Unlock()
+		if ok {
+			return true
+		}
+	}
+
+	arg1 := randomHex(20)
+	answer, err := upstream.AcwScV2(arg1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	s.mu.Lock()
+	s.wafAnswers[answer] = true
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte("<html><script>var arg1='" + arg1 + "';</script></html>"))
+	return false
+}
+
+func (s *Server) handleSignIn(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.authenticateLocked(w, r)
+	if !ok {
+		return
+	}
+	today := carbon.Now().ToDateString()
+	if user.lastSignIn == today {
+		writeJSON(w, http.StatusOK, false, "今日已签到", nil)
+		return
+	}
+	user.lastSignIn = today
+	user.Quota += s.signInQuota
+	writeJSON(w, http.StatusOK, true, "签到成功", map[string]any{"quota": s.signInQuota})
+}
+
+func (s *Server) handleSelf(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.authenticateLocked(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, true, "", map[string]any{
+		"id":       user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"status":   user.Status,
+		"group":    user.Group,
+		"quota":    user.Quota,
+	})
+}
+
+// Tokens 返回用户的令牌快照，按 ID 倒序。
+func (s *Server) Tokens(userID int) []Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokensLocked(userID)
+}
+
+func (s *Server) tokensLocked(userID int) []Token {
+	tokens := make([]Token, 0)
+	for i := len(s.tokens) - 1; i >= 0; i-- {
+		if s.tokens[i].UserID == userID {
+			tokens = append(tokens, *s.tokens[i])
+		}
+	}
+	return tokens
+}
+
+func (s *Server) handleListTokens(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.authenticateLocked(w, r)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+	size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+
+	owned := s.tokensLocked(user.ID)
+	start := min((page-1)*size, len(owned))
+	end := min(start+size, len(owned))
+	writeJSON(w, http.StatusOK, true, "", map[string]any{
+		"page":      page,
+		"page_size": size,
+		"total":     len(owned),
+		"items":     owned[start:end],
+	})
+}
+
+func (s *Server) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.authenticateLocked(w, r)
+	if !ok {
+		return
+	}
+	var req Token
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeJSON(w, http.StatusOK, false, "无效的参数", nil)
+		return
+	}
+	s.tokens = append(s.tokens, &Token{
+		ID:             s.nextTokenID,
+		UserID:         user.ID,
+		Name:           req.Name,
+		Key:            randomHex(24),
+		Status:         1,
+		CreatedTime:    carbon.Now().Timestamp(),
+		AccessedTime:   carbon.Now().Timestamp(),
+		ExpiredTime:    req.ExpiredTime,
+		RemainQuota:    req.RemainQuota,
+		UnlimitedQuota: req.UnlimitedQuota,
+		Group:          req.Group,
+	})
+	s.nextTokenID++
+	writeJSON(w, http.StatusOK, true, "", nil)
+}
+
+func (s *Server) handleDeleteToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.authenticateLocked(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/token/"))
+	if err != nil {
+		writeJSON(w, http.StatusOK, false, "无效的参数", nil)
+		return
+	}
+	for i, token := range s.tokens {
+		if token.ID == id && token.UserID == user.ID {
+			s.tokens = append(s.tokens[:i], s.tokens[i+1:]...)
+			writeJSON(w, http.StatusOK, true, "", nil)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, false, "令牌不存在", nil)
+}
+
+// AddLog 为用户追加一条日志并扣减 quota，ID 与 CreatedAt 为空时自动填充。
+func (s *Server) AddLog(userID int, log Log) Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log.UserID = userID
+	if log.ID == 0 {
+		log.ID = int64(len(s.logs) + 1)
+	}
+	if log.CreatedAt == 0 {
+		log.CreatedAt = carbon.Now().Timestamp()
+	}
+	if log.Type == 0 {
+		log.Type = 2
+	}
+	if user, ok := s.users[userID]; ok && log.Type == 2 {
+		user.Quota -= log.Quota
+	}
+	s.logs = append(s.logs, log)
+	return log
+}
+
+func (s *Server) handleListLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.authenticateLocked(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("p"))
+	size, _ := strconv.Atoi(query.Get("page_size"))
+	logType, _ := strconv.Atoi(query.Get("type"))
+	start, _ := strconv.ParseInt(query.Get("start_timestamp"), 10, 64)
+	end, _ := strconv.ParseInt(query.Get("end_timestamp"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+
+	matched := make([]Log, 0)
+	for i := len(s.logs) - 1; i >= 0; i-- {
+		log := s.logs[i]
+		if log.UserID != user.ID || (logType != 0 && log.Type != logType) {
+			continue
+		}
+		if (start > 0 && log.CreatedAt < start) || (end > 0 && log.CreatedAt > end) {
+			continue
+		}
+		matched = append(matched, log)
+	}
+	from := min((page-1)*size, len(matched))
+	to := min(from+size, len(matched))
+	writeJSON(w, http.StatusOK, true, "", map[string]any{
+		"page":      page,
+		"page_size": size,
+		"total":     len(matched),
+		"items":     matched[from:to],
+	})
+}
+
+// AddRedeemCode 登记一个可使用一次的兑换码。
+func (s *Server) AddRedeemCode(code string, quota int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redeemCodes[code] = quota
+}
+
+func (s *Server) handleTopup(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.authenticateLocked(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Key string `json:"key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Key == "" {
+		writeJSON(w, http.StatusOK, false, "请输入兑换码", nil)
+		return
+	}
+	quota, ok := s.redeemCodes[req.Key]
+	if !ok {
+		writeJSON(w, http.StatusOK, false, "无效的兑换码", nil)
+		return
+	}
+	delete(s.redeemCodes, req.Key)
+	user.Quota += quota
+	writeJSON(w, http.StatusOK, true, "", quota)
+}
+
+// handleChatCompletions 以令牌鉴权并回显最后一条消息，支持 stream，每次调用记录一条消费日志。
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	key := strings.TrimPrefix(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), "sk-")
+	var token *Token
+	for _, t := range s.tokens {
+		if t.Key == key && t.Status == 1 {
+			token = t
+			break
+		}
+	}
+	if token == nil {
+		s.mu.Unlock()
+		writeOpenAIError(w, http.StatusUnauthorized, "无效的令牌")
+		return
+	}
+	token.AccessedTime = carbon.Now().Timestamp()
+	s.mu.Unlock()
+
+	var req struct {
+		Model    string `json:"model"`
+		Stream   bool   `json:"stream"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
+		writeOpenAIError(w, http.StatusBadRequest, "无效的请求")
+		return
+	}
+	reply := req.Messages[len(req.Messages)-1].Content
+	usage := map[string]int{"prompt_tokens": len(req.Messages) * 10, "completion_tokens": len(reply)}
+	s.AddLog(token.UserID, Log{
+		Type:             2,
+		TokenName:        token.Name,
+		ModelName:        req.Model,
+		Quota:            int64(usage["prompt_tokens"] + usage["completion_tokens"]),
+		PromptTokens:     int64(usage["prompt_tokens"]),
+		CompletionTokens: int64(usage["completion_tokens"]),
+		IsStream:         req.Stream,
+	})
+
+	if !req.Stream {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object":  "chat.completion",
+			"model":   req.Model,
+			"choices": []any{map[string]any{"index": 0, "message": map[string]string{"role": "assistant", "content": reply}}},
+			"usage":   usage,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	chunks := []map[string]any{
+		{"object": "chat.completion.chunk", "model": req.Model, "choices": []any{map[string]any{"index": 0, "delta": map[string]string{"content": reply}}}},
+		{"object": "chat.completion.chunk", "model": req.Model, "choices": []any{}, "usage": usage},
+	}
+	for _, chunk := range chunks {
+		data, _ := json.Marshal(chunk)
+		_, _ = w.Write([]byte("data: " + string(data) + "\n\n"))
+	}
+	_, _ = w.Write([]byte("data: [DONE]\n\n"))
+}
+
+// authenticateLocked 按真实站点的规则校验 session 与 new-api-user 头，失败时写入 401。
+func (s *Server) authenticateLocked(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	cookie, err := r.Cookie("session")
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, false, "无权进行此操作，未登录且未提供 access token", nil)
+		return nil, false
+	}
+	user, ok := s.users[s.sessions[cookie.Value]]
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, false, "无权进行此操作，未登录且未提供 access token", nil)
+		return nil, false
+	}
+	if header := r.Header.Get("new-api-user"); header != "" {
+		if id, err := strconv.Atoi(header); err != nil || id != user.ID {
+			writeJSON(w, http.StatusUnauthorized, false, "无权进行此操作，New-Api-User 与登录用户不匹配", nil)
+			return nil, false
+		}
+	}
+	if user.Status != 1 {
+		writeJSON(w, http.StatusForbidden, false, "用户已被封禁", nil)
+		return nil, false
+	}
+	if s.rotate {
+		http.SetCookie(w, &http.Cookie{
+			Name:     "session",
+			Value:    s.issueSessionLocked(user),
+			Path:     "/",
+			HttpOnly: true,
+		})
+	}
+	return user, true
+}
+
+// issueSessionLocked 生成 gorilla/sessions 格式的 session：base64(date|base64(gob)|mac)。
+func (s *Server) issueSessionLocked(user *User) string {
+	values := map[interface{}]interface{}{
+		"id":       user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"status":   user.Status,
+		"group":    user.Group,
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		panic(err)
+	}
+	date := strconv.FormatInt(carbon.Now().TimestampNano(), 10)
+	payload := date + "|" + base64.URLEncoding.EncodeToString(buf.Bytes())
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	session := base64.URLEncoding.EncodeToString([]byte(payload + "|" + hex.EncodeToString(mac.Sum(nil))))
+	s.sessions[session] = user.ID
+	return session
+}
+
+func writeJSON(w http.ResponseWriter, status int, success bool, message string, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": success,
+		"message": message,
+		"data":    data,
+	})
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"message": message, "type": "invalid_request_error"},
+	})
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,105 @@
+package upstream
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"anyrouter-checkin/internal/config"
+)
+
+// apiTimeout 为控制台 JSON 接口的整体超时，代理转发不受此限制。
+const apiTimeout = 30 * time.Second
+
+var (
+	transports   = make(map[string]*http.Transport)
+	transportsMu sync.Mutex
+)
+
+// ValidateProxyURL 校验出口代理地址，空字符串表示直连。
+func ValidateProxyURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ErrInvalidProxyURL
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	}
+	return ErrInvalidProxyURL
+}
+
+// Transport 按出口代理地址返回共享的 Transport，同一出口的请求复用连接池。
+func Transport(proxyURL string) (*http.Transport, error) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if transport, ok := transports[proxyURL]; ok {
+		return transport, nil
+	}
+
+	proxyFunc := http.ProxyFromEnvironment
+	if proxyURL != "" {
+		if err := ValidateProxyURL(proxyURL); err != nil {
+			return nil, err
+		}
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, ErrInvalidProxyURL
+		}
+		proxyFunc = http.ProxyURL(u)
+	}
+
+	cfg := transportConfig()
+	transport := &http.Transport{
+		Proxy: proxyFunc,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConns,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.DialTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	transports[proxyURL] = transport
+	return transport, nil
+}
+
+// ResetTransports 丢弃共享的 Transport，之后的请求按当前 proxy 配置重新创建，进行中的请求不受影响。
+func ResetTransports() {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	for key, transport := range transports {
+		transport.CloseIdleConnections()
+		delete(transports, key)
+	}
+}
+
+func transportConfig() config.ProxyConfig {
+	if cfg := config.Get(); cfg != nil {
+		return cfg.Proxy
+	}
+	return config.ProxyConfig{
+		DialTimeout:           10 * time.Second,
+		ResponseHeaderTimeout: 60 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          100,
+	}
+}
+
+func httpClient(proxyURL string) (*http.Client, error) {
+	transport, err := Transport(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: apiTimeout}, nil
+}
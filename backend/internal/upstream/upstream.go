@@ -0,0 +1,208 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+const DefaultBaseURL = "https://anyrouter.top"
+
+// QuotaPerUnit 为上游 quota 与美元额度的换算比例。
+// quota 返回值比实际美元额度放大 100 倍，因此需除以 500000（5000 * 100）。
+var QuotaPerUnit = decimal.NewFromInt(500000)
+
+var (
+	ErrUnauthorized     = errors.New("session 无效")
+	ErrUnknownChallenge = errors.New("未知的 WAF 挑战类型")
+	ErrInvalidProxyURL  = errors.New("代理地址无效，仅支持 http/https/socks5")
+)
+
+// APIError 表示上游返回了非成功状态码或 success=false。
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s 请求失败: %d %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// ChallengeError 表示 WAF 挑战页无法识别或求解，Solver 为空时表示没有匹配的求解器。
+type ChallengeError struct {
+	Solver string
+	Err    error
+}
+
+func (e *ChallengeError) Error() string {
+	if e.Solver == "" {
+		return "WAF 挑战处理失败: " + e.Err.Error()
+	}
+	return fmt.Sprintf("WAF 挑战处理失败(%s): %v", e.Solver, e.Err)
+}
+
+func (e *ChallengeError) Unwrap() error {
+	return e.Err
+}
+
+// Identity 描述一次上游请求使用的出口代理与浏览器指纹。
+type Identity struct {
+	ProxyURL        string
+	UserAgent       string
+	SecChUa         string
+	SecChUaMobile   string
+	SecChUaPlatform string
+	AcceptLanguage  string
+}
+
+// DefaultIdentity 返回直连且使用默认 macOS Chrome 指纹的身份。
+func DefaultIdentity() Identity {
+	return Identity{
+		UserAgent:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36",
+		SecChUa:         `"Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144"`,
+		SecChUaMobile:   "?0",
+		SecChUaPlatform: `"macOS"`,
+		AcceptLanguage:  "zh-CN,zh;q=0.9,en-US;q=0.8,en;q=0.7",
+	}
+}
+
+// ApplyHeaders 写入指纹相关的请求头，空字段保持不变。
+func (i Identity) ApplyHeaders(h http.Header) {
+	set := func(key, value string) {
+		if value != "" {
+			h.Set(key, value)
+		}
+	}
+	set("user-agent", i.UserAgent)
+	set("accept-language", i.AcceptLanguage)
+	set("sec-ch-ua", i.SecChUa)
+	set("sec-ch-ua-mobile", i.SecChUaMobile)
+	set("sec-ch-ua-platform", i.SecChUaPlatform)
+}
+
+// cacheKey 用于区分 WAF Cookie 缓存：不同出口或 UA 的 Cookie 不可共用。
+func (i Identity) cacheKey() string {
+	return i.ProxyURL + "|" + i.UserAgent
+}
+
+// Credential 为调用上游接口所需的登录态。
+// 上游在响应中下发新的 session Cookie 时会调用 OnSessionRotated，调用方可据此持久化。
+type Credential struct {
+	Session          string
+	UserID           int
+	Identity         Identity
+	OnSessionRotated func(session string)
+}
+
+// Apply 写入指纹、new-api-user 与 Cookie 请求头，wafCookie 为 "name=value" 形式，可为空。
+func (c Credential) Apply(h http.Header, wafCookie string) {
+	c.Identity.ApplyHeaders(h)
+	if c.UserID > 0 {
+		h.Set("new-api-user", strconv.Itoa(c.UserID))
+	}
+	cookie := "session=" + c.Session
+	if wafCookie != "" {
+		cookie += "; " + wafCookie
+	}
+	h.Set("cookie", cookie)
+}
+
+type SignInResult struct {
+	Success       bool
+	AlreadySigned bool
+	Message       string
+	Raw           string
+}
+
+type UserSelf struct {
+	ID       int
+	Username string
+	Role     int
+	Status   int
+	Quota    int64
+}
+
+// Balance 返回以美元计的剩余额度。
+func (u UserSelf) Balance() decimal.Decimal {
+	return decimal.NewFromInt(u.Quota).DivRound(QuotaPerUnit, 2)
+}
+
+type Token struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Key            string `json:"key"`
+	Status         int    `json:"status"`
+	CreatedTime    int64  `json:"created_time"`
+	AccessedTime   int64  `json:"accessed_time"`
+	ExpiredTime    int64  `json:"expired_time"`
+	RemainQuota    int64  `json:"remain_quota"`
+	UsedQuota      int64  `json:"used_quota"`
+	UnlimitedQuota bool   `json:"unlimited_quota"`
+	Group          string `json:"group"`
+}
+
+// TokenCreate 为创建令牌的参数，ExpiredTime 为 -1 表示永不过期。
+type TokenCreate struct {
+	Name           string `json:"name"`
+	RemainQuota    int64  `json:"remain_quota"`
+	ExpiredTime    int64  `json:"expired_time"`
+	UnlimitedQuota bool   `json:"unlimited_quota"`
+	Group          string `json:"group,omitempty"`
+}
+
+type TokenPage struct {
+	Items []Token
+	Total int64
+}
+
+type LogQuery struct {
+	Type           int
+	Page           int
+	PageSize       int
+	StartTimestamp int64
+	EndTimestamp   int64
+}
+
+type LogEntry struct {
+	ID               int64  `json:"id"`
+	CreatedAt        int64  `json:"created_at"`
+	Type             int    `json:"type"`
+	Content          string `json:"content"`
+	TokenName        string `json:"token_name"`
+	ModelName        string `json:"model_name"`
+	Quota            int64  `json:"quota"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	UseTime          int    `json:"use_time"`
+	IsStream         bool   `json:"is_stream"`
+	Group            string `json:"group"`
+}
+
+type LogPage struct {
+	Items []LogEntry
+	Total int64
+}
+
+// Client 封装 AnyRouter（New-API）控制台接口。
+type Client interface {
+	BaseURL() string
+	SignIn(ctx context.Context, cred Credential) (*SignInResult, error)
+	Self(ctx context.Context, cred Credential) (*UserSelf, error)
+	Tokens(ctx context.Context, cred Credential, page, size int) (*TokenPage, error)
+	CreateToken(ctx context.Context, cred Credential, token TokenCreate) error
+	DeleteToken(ctx context.Context, cred Credential, id int) error
+	Logs(ctx context.Context, cred Credential, query LogQuery) (*LogPage, error)
+	// Redeem 使用兑换码充值，返回增加的 quota。
+	Redeem(ctx context.Context, cred Credential, code string) (int64, error)
+	// WafCookie 返回指定身份可用的 WAF Cookie（"name=value"），站点未下发挑战时为空。
+	WafCookie(ctx context.Context, identity Identity) (string, error)
+	InvalidateWafCookie(identity Identity)
+}
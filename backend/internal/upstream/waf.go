@@ -0,0 +1,89 @@
+package upstream
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/dromara/carbon/v2"
+	"go.uber.org/zap"
+)
+
+// wafCookieTTLMinutes 为 WAF Cookie 的本地缓存分钟数，上游返回挑战页时会提前失效。
+const wafCookieTTLMinutes = 30
+
+type wafCacheKey struct {
+	site     string
+	identity string
+}
+
+type wafCacheEntry struct {
+	cookie    string
+	expiresAt *carbon.Carbon
+}
+
+var (
+	wafCache   = make(map[wafCacheKey]wafCacheEntry)
+	wafCacheMu sync.Mutex
+)
+
+// getWafCookie 返回指定站点与身份（出口代理 + UA）对应的 WAF Cookie，缓存未命中或过期时重新求解。
+func getWafCookie(ctx context.Context, baseURL string, identity Identity) (string, error) {
+	key := wafCacheKey{site: strings.TrimRight(baseURL, "/"), identity: identity.cacheKey()}
+
+	wafCacheMu.Lock()
+	entry, ok := wafCache[key]
+	wafCacheMu.Unlock()
+	if ok && carbon.Now().Lt(entry.expiresAt) {
+		return entry.cookie, nil
+	}
+
+	cookie, err := fetchWafCookie(ctx, key.site, identity)
+	if err != nil {
+		return "", err
+	}
+
+	wafCacheMu.Lock()
+	wafCache[key] = wafCacheEntry{cookie: cookie, expiresAt: carbon.Now().AddMinutes(wafCookieTTLMinutes)}
+	wafCacheMu.Unlock()
+	return cookie, nil
+}
+
+func invalidateWafCookie(baseURL string, identity Identity) {
+	key := wafCacheKey{site: strings.TrimRight(baseURL, "/"), identity: identity.cacheKey()}
+	wafCacheMu.Lock()
+	delete(wafCache, key)
+	wafCacheMu.Unlock()
+}
+
+func fetchWafCookie(ctx context.Context, baseURL string, identity Identity) (string, error) {
+	client, err := httpClient(identity.ProxyURL)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/", nil)
+	if err != nil {
+		return "", err
+	}
+	identity.ApplyHeaders(req.Header)
+	req.Header.Set("accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", err
+	}
+
+	cookie, err := solveChallenge(body)
+	if err != nil {
+		zap.L().Error("WAF 挑战处理失败", zap.String("site", baseURL), zap.Error(err))
+		return "", err
+	}
+	return cookie, nil
+}
@@ -12,14 +12,18 @@ type zapWriter struct {
 	level  zapcore.Level
 }
 
+// level 为全局日志级别，可在运行时通过 SetLevel 调整。
+var level = zap.NewAtomicLevel()
+
 func Init(mode string) (*zap.Logger, error) {
-	normalized := strings.ToLower(strings.TrimSpace(mode))
 	var cfg zap.Config
-	if normalized == "release" || normalized == "prod" || normalized == "production" {
+	if isRelease(mode) {
 		cfg = zap.NewProductionConfig()
 	} else {
 		cfg = zap.NewDevelopmentConfig()
 	}
+	level.SetLevel(cfg.Level.Level())
+	cfg.Level = level
 	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	instance, err := cfg.Build()
 	if err != nil {
@@ -29,6 +33,29 @@ func Init(mode string) (*zap.Logger, error) {
 	return instance, nil
 }
 
+// SetLevel 调整日志级别，name 为空时 release 模式使用 info，其他模式使用 debug。
+// 输出格式由 Init 时的模式决定，运行时不会改变。
+func SetLevel(mode, name string) error {
+	if name == "" {
+		if isRelease(mode) {
+			name = "info"
+		} else {
+			name = "debug"
+		}
+	}
+	parsed, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(parsed)
+	return nil
+}
+
+func isRelease(mode string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(mode))
+	return normalized == "release" || normalized == "prod" || normalized == "production"
+}
+
 func Writer(logger *zap.Logger, level zapcore.Level) *zapWriter {
 	return &zapWriter{logger: logger, level: level}
 }
@@ -6,6 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// 写入响应时同时记录在上下文中，供审计等中间件读取。
+const (
+	CodeKey = "response_code"
+	DataKey = "response_data"
+)
+
 type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -13,6 +19,8 @@ type Response struct {
 }
 
 func Success(c *gin.Context, data interface{}) {
+	c.Set(CodeKey, 0)
+	c.Set(DataKey, data)
 	c.JSON(http.StatusOK, Response{
 		Code:    0,
 		Message: "success",
@@ -21,15 +29,42 @@ func Success(c *gin.Context, data interface{}) {
 }
 
 func Error(c *gin.Context, code int, message string) {
+	c.Set(CodeKey, code)
+	c.JSON(http.StatusOK, Response{
+		Code:    code,
+		Message: message,
+	})
+}
+
+// ErrorWithData 返回错误并附带详细信息，例如字段级的校验错误。
+func ErrorWithData(c *gin.Context, code int, message string, data interface{}) {
+	c.Set(CodeKey, code)
 	c.JSON(http.StatusOK, Response{
 		Code:    code,
 		Message: message,
+		Data:    data,
 	})
 }
 
+// Attachment 以附件形式返回文件内容，视为成功响应。
+func Attachment(c *gin.Context, filename, contentType string, data []byte) {
+	c.Set(CodeKey, 0)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
 func Unauthorized(c *gin.Context) {
+	c.Set(CodeKey, 401)
 	c.JSON(http.StatusUnauthorized, Response{
 		Code:    401,
 		Message: "unauthorized",
 	})
 }
+
+func Forbidden(c *gin.Context) {
+	c.Set(CodeKey, 403)
+	c.JSON(http.StatusForbidden, Response{
+		Code:    403,
+		Message: "forbidden",
+	})
+}
@@ -2,6 +2,13 @@ import request from '@/utils/request'
 
 export interface LoginResponse {
   token: string
+  refresh_token: string
+  expires_in: number
+}
+
+export interface AuthMethods {
+  local_login: boolean
+  oidc: boolean
 }
 
 export interface ProfileResponse {
@@ -12,10 +19,18 @@ export function login(username: string, password: string): Promise<LoginResponse
   return request.post<LoginResponse, LoginResponse>('/auth/login', { username, password })
 }
 
+export function getAuthMethods(): Promise<AuthMethods> {
+  return request.get<AuthMethods, AuthMethods>('/auth/methods')
+}
+
+export function logout(): Promise<null> {
+  return request.post<null, null>('/auth/logout')
+}
+
 export function getProfile(): Promise<ProfileResponse> {
   return request.get<ProfileResponse, ProfileResponse>('/auth/profile')
 }
 
-export function changePassword(oldPassword: string, newPassword: string): Promise<null> {
-  return request.put<null, null>('/auth/password', { old_password: oldPassword, new_password: newPassword })
+export function changePassword(oldPassword: string, newPassword: string): Promise<LoginResponse> {
+  return request.put<LoginResponse, LoginResponse>('/auth/password', { old_password: oldPassword, new_password: newPassword })
 }
@@ -3,13 +3,18 @@ import { ref, computed } from 'vue'
 
 export const useAuthStore = defineStore('auth', () => {
   const token = ref(localStorage.getItem('token') || '')
+  const refreshToken = ref(localStorage.getItem('refresh_token') || '')
   const username = ref('')
 
   const isLoggedIn = computed(() => !!token.value)
 
-  function setToken(newToken: string) {
+  function setToken(newToken: string, newRefreshToken = '') {
     token.value = newToken
     localStorage.setItem('token', newToken)
+    if (newRefreshToken) {
+      refreshToken.value = newRefreshToken
+      localStorage.setItem('refresh_token', newRefreshToken)
+    }
   }
 
   function setUser(name: string) {
@@ -18,9 +23,11 @@ export const useAuthStore = defineStore('auth', () => {
 
   function logout() {
     token.value = ''
+    refreshToken.value = ''
     username.value = ''
     localStorage.removeItem('token')
+    localStorage.removeItem('refresh_token')
   }
 
-  return { token, username, isLoggedIn, setToken, setUser, logout }
+  return { token, refreshToken, username, isLoggedIn, setToken, setUser, logout }
 })
@@ -1,4 +1,4 @@
-import axios from 'axios'
+import axios, { type InternalAxiosRequestConfig } from 'axios'
 import { useAuthStore } from '@/stores/auth'
 import router from '@/router'
 
@@ -15,6 +15,33 @@ request.interceptors.request.use((config) => {
   return config
 })
 
+// 并发请求同时过期时只刷新一次
+let refreshing: Promise<boolean> | null = null
+
+function refreshToken(): Promise<boolean> {
+  const auth = useAuthStore()
+  if (!auth.refreshToken) {
+    return Promise.resolve(false)
+  }
+  if (!refreshing) {
+    refreshing = axios
+      .post('/api/auth/refresh', { refresh_token: auth.refreshToken })
+      .then((res) => {
+        const { code, data } = res.data
+        if (code !== 0) {
+          return false
+        }
+        auth.setToken(data.token, data.refresh_token)
+        return true
+      })
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
 request.interceptors.response.use(
   (response) => {
     const { code, message, data } = response.data
@@ -23,8 +50,13 @@ request.interceptors.response.use(
     }
     return Promise.reject(new Error(message || '请求失败'))
   },
-  (error) => {
+  async (error) => {
+    const config = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined
     if (error.response?.status === 401) {
+      if (config && !config._retried && (await refreshToken())) {
+        config._retried = true
+        return request(config)
+      }
       const auth = useAuthStore()
       auth.logout()
       router.push('/login')